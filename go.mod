@@ -5,3 +5,9 @@ go 1.23.4
 require golang.org/x/net v0.33.0
 
 require github.com/joho/godotenv v1.5.1
+
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/text v0.21.0
+)
@@ -0,0 +1,303 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxStylesheetSize bounds how much of a stylesheet is scanned for fonts.
+	maxStylesheetSize = 5 * 1024 * 1024
+	// minCompressibleSize skips compression warnings for tiny files.
+	minCompressibleSize = 1024
+)
+
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+var fontExtensions = []string{".woff", ".woff2", ".ttf", ".otf", ".eot"}
+
+type Asset struct {
+	URL             string   `json:"url"`
+	Kind            LinkKind `json:"kind"`
+	StatusCode      int      `json:"status_code,omitempty"`
+	ContentType     string   `json:"content_type,omitempty"`
+	Size            int64    `json:"size"`
+	ContentEncoding string   `json:"content_encoding,omitempty"`
+	CacheControl    string   `json:"cache_control,omitempty"`
+	Expires         string   `json:"expires,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	Count           int      `json:"count"`
+	Referrers       []string `json:"referrers"`
+}
+
+// AssetReport is the inventory of every asset referenced by crawled pages,
+// with the URLs of problem assets grouped by issue.
+type AssetReport struct {
+	MaxImageSize    int64    `json:"max_image_size"`
+	Assets          []Asset  `json:"assets"`
+	Broken          []string `json:"broken"`
+	OversizedImages []string `json:"oversized_images"`
+	Uncompressed    []string `json:"uncompressed"`
+	Uncached        []string `json:"uncached"`
+}
+
+func (asset *Asset) isBroken() bool {
+	return asset.Error != "" || asset.StatusCode >= 400
+}
+
+// isCompressible reports whether the asset's content type benefits from
+// gzip or brotli; images other than SVG and woff fonts are already compressed.
+func (asset *Asset) isCompressible() bool {
+	mediaType, _, _ := mime.ParseMediaType(asset.ContentType)
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.Contains(mediaType, "javascript"),
+		strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "json"),
+		mediaType == "font/ttf", mediaType == "font/otf",
+		mediaType == "application/vnd.ms-fontobject":
+		return true
+	}
+	return false
+}
+
+func (asset *Asset) isCached() bool {
+	cacheControl := strings.ToLower(asset.CacheControl)
+	if strings.Contains(cacheControl, "no-store") || strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "max-age=0") {
+		return false
+	}
+	return strings.Contains(cacheControl, "max-age") || asset.Expires != ""
+}
+
+func (cfg *Config) addAsset(fromRawURL string, link Link) (asset *Asset, isNew bool) {
+	cfg.Mu.Lock()
+	defer cfg.Mu.Unlock()
+
+	asset, ok := cfg.Assets[link.URL]
+	if !ok {
+		asset = &Asset{URL: link.URL, Kind: link.Kind, Referrers: []string{}}
+		cfg.Assets[link.URL] = asset
+	}
+	asset.Count++
+	if len(asset.Referrers) < maxInlinkReferrers && !slices.Contains(asset.Referrers, fromRawURL) {
+		asset.Referrers = append(asset.Referrers, fromRawURL)
+	}
+	return asset, !ok
+}
+
+// addPageAsset records an asset against both the page that uses it and the
+// site-wide inventory.
+func (cfg *Config) addPageAsset(pageKey, pageURL string, link Link) {
+	cfg.Mu.Lock()
+	if data, ok := cfg.Pages[pageKey]; ok && !slices.Contains(data.Assets, link) {
+		data.Assets = append(data.Assets, link)
+	}
+	cfg.Mu.Unlock()
+
+	cfg.addAsset(pageURL, link)
+}
+
+// CheckAssets fetches every collected asset, plus the fonts referenced by
+// stylesheets, and reports broken, oversized, uncompressed and uncached ones.
+func (cfg *Config) CheckAssets(maxImageSize int64) *AssetReport {
+	cfg.Mu.Lock()
+	pending := make([]*Asset, 0, len(cfg.Assets))
+	for _, asset := range cfg.Assets {
+		pending = append(pending, asset)
+	}
+	cfg.Mu.Unlock()
+
+	// stylesheets can reveal fonts, which are then checked in the next round
+	for len(pending) > 0 {
+		var (
+			fonts []stylesheetFont
+			mu    sync.Mutex
+			wg    sync.WaitGroup
+		)
+		for _, asset := range pending {
+			wg.Add(1)
+			go func(asset *Asset) {
+				cfg.ConcurrencyControl <- struct{}{}
+				defer func() {
+					<-cfg.ConcurrencyControl
+					wg.Done()
+				}()
+
+				time.Sleep(cfg.RateLimit)
+				fontURLs := cfg.checkAsset(asset)
+
+				mu.Lock()
+				for _, fontURL := range fontURLs {
+					fonts = append(fonts, stylesheetFont{stylesheet: asset.URL, font: fontURL})
+				}
+				mu.Unlock()
+			}(asset)
+		}
+		wg.Wait()
+
+		pending = nil
+		for _, font := range fonts {
+			if asset, isNew := cfg.addAsset(font.stylesheet, Link{URL: font.font, Kind: LinkFont}); isNew {
+				pending = append(pending, asset)
+			}
+		}
+	}
+
+	return cfg.buildAssetReport(maxImageSize)
+}
+
+type stylesheetFont struct {
+	stylesheet string
+	font       string
+}
+
+func (cfg *Config) buildAssetReport(maxImageSize int64) *AssetReport {
+	report := &AssetReport{
+		MaxImageSize:    maxImageSize,
+		Assets:          []Asset{},
+		Broken:          []string{},
+		OversizedImages: []string{},
+		Uncompressed:    []string{},
+		Uncached:        []string{},
+	}
+
+	cfg.Mu.Lock()
+	for _, asset := range cfg.Assets {
+		report.Assets = append(report.Assets, *asset)
+	}
+	cfg.Mu.Unlock()
+
+	sort.Slice(report.Assets, func(i, j int) bool {
+		return report.Assets[i].URL < report.Assets[j].URL
+	})
+
+	for _, asset := range report.Assets {
+		if asset.isBroken() {
+			report.Broken = append(report.Broken, asset.URL)
+			continue
+		}
+		if asset.Kind == LinkImage && asset.Size > maxImageSize {
+			report.OversizedImages = append(report.OversizedImages, asset.URL)
+		}
+		if asset.isCompressible() && asset.ContentEncoding == "" && asset.Size >= minCompressibleSize {
+			report.Uncompressed = append(report.Uncompressed, asset.URL)
+		}
+		if !asset.isCached() {
+			report.Uncached = append(report.Uncached, asset.URL)
+		}
+	}
+	return report
+}
+
+// checkAsset fetches the asset, recording its status and headers, and
+// returns the fonts referenced if it turns out to be a stylesheet.
+func (cfg *Config) checkAsset(asset *Asset) []string {
+	req, err := http.NewRequest("GET", asset.URL, nil)
+	if err != nil {
+		asset.Error = err.Error()
+		return nil
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	res, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		asset.Error = fmt.Sprintf("got Network error: %v", err)
+		return nil
+	}
+	defer res.Body.Close()
+
+	asset.StatusCode = res.StatusCode
+	asset.ContentType = res.Header.Get("Content-Type")
+	asset.ContentEncoding = res.Header.Get("Content-Encoding")
+	asset.CacheControl = res.Header.Get("Cache-Control")
+	asset.Expires = res.Header.Get("Expires")
+
+	body := &countingReader{r: res.Body}
+	defer func() {
+		io.Copy(io.Discard, body)
+		asset.Size = body.n
+	}()
+
+	mediaType, _, _ := mime.ParseMediaType(asset.ContentType)
+	if asset.Kind != LinkStylesheet || mediaType != "text/css" || res.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	css, err := decodeContentEncoding(asset.ContentEncoding, body)
+	if err != nil {
+		return nil
+	}
+	defer css.Close()
+
+	cssBytes, err := io.ReadAll(io.LimitReader(css, maxStylesheetSize))
+	if err != nil {
+		return nil
+	}
+	return fontsFromCSS(string(cssBytes), res.Request.URL)
+}
+
+// fontsFromCSS returns the font files referenced by url() in a stylesheet.
+func fontsFromCSS(css string, stylesheetURL *url.URL) []string {
+	var fonts []string
+	for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		ref, err := url.Parse(strings.TrimSpace(match[1]))
+		if err != nil {
+			continue
+		}
+		resolved := stylesheetURL.ResolveReference(ref)
+		if resolved.Scheme != "http" && resolved.Scheme != "https" {
+			continue
+		}
+		if !slices.Contains(fontExtensions, strings.ToLower(path.Ext(resolved.Path))) {
+			continue
+		}
+		fonts = append(fonts, resolved.String())
+	}
+	return fonts
+}
+
+func (report *AssetReport) writeText(w io.Writer) {
+	writeSectionHeader(w, "ASSETS")
+	fmt.Fprintf(w, "%d assets checked\n", len(report.Assets))
+
+	assets := map[string]Asset{}
+	for _, asset := range report.Assets {
+		assets[asset.URL] = asset
+	}
+
+	fmt.Fprintf(w, "\nBroken assets: %d\n", len(report.Broken))
+	for _, u := range report.Broken {
+		asset := assets[u]
+		status := asset.Error
+		if status == "" {
+			status = fmt.Sprint(asset.StatusCode)
+		}
+		fmt.Fprintf(w, "  - %s (%s): %s (used on %s)\n", u, asset.Kind, status, strings.Join(asset.Referrers, ", "))
+	}
+
+	fmt.Fprintf(w, "\nImages larger than %d KB: %d\n", report.MaxImageSize/1024, len(report.OversizedImages))
+	for _, u := range report.OversizedImages {
+		fmt.Fprintf(w, "  - %s: %d KB\n", u, assets[u].Size/1024)
+	}
+
+	fmt.Fprintf(w, "\nAssets served without compression: %d\n", len(report.Uncompressed))
+	for _, u := range report.Uncompressed {
+		fmt.Fprintf(w, "  - %s (%s, %d KB)\n", u, assets[u].ContentType, assets[u].Size/1024)
+	}
+
+	fmt.Fprintf(w, "\nAssets served without caching headers: %d\n", len(report.Uncached))
+	for _, u := range report.Uncached {
+		fmt.Fprintf(w, "  - %s\n", u)
+	}
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestFontsFromCSS(t *testing.T) {
+	stylesheetURL, _ := url.Parse("https://blog.boot.dev/static/css/main.css")
+
+	tests := []struct {
+		name     string
+		css      string
+		expected []string
+	}{
+		{
+			name:     "relative font in @font-face",
+			css:      `@font-face { font-family: "Inter"; src: url("../fonts/inter.woff2") format("woff2"); }`,
+			expected: []string{"https://blog.boot.dev/static/fonts/inter.woff2"},
+		},
+		{
+			name:     "unquoted and absolute urls",
+			css:      `@font-face { src: url(/fonts/a.ttf), url(https://cdn.boot.dev/b.WOFF); }`,
+			expected: []string{"https://blog.boot.dev/fonts/a.ttf", "https://cdn.boot.dev/b.WOFF"},
+		},
+		{
+			name:     "ignores images and data urls",
+			css:      `body { background: url('bg.png'); } @font-face { src: url(data:font/woff2;base64,AAAA); }`,
+			expected: nil,
+		},
+		{
+			name:     "font with query string",
+			css:      `@font-face { src: url('icons.eot?v=3#iefix'); }`,
+			expected: []string{"https://blog.boot.dev/static/css/icons.eot?v=3#iefix"},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := fontsFromCSS(tc.css, stylesheetURL)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildAssetReport(t *testing.T) {
+	cfg := &Config{
+		Assets: map[string]*Asset{
+			"https://blog.boot.dev/big.jpg":    {URL: "https://blog.boot.dev/big.jpg", Kind: LinkImage, StatusCode: 200, ContentType: "image/jpeg", Size: 300 * 1024, CacheControl: "max-age=3600"},
+			"https://blog.boot.dev/app.js":     {URL: "https://blog.boot.dev/app.js", Kind: LinkScript, StatusCode: 200, ContentType: "application/javascript", Size: 40 * 1024},
+			"https://blog.boot.dev/main.css":   {URL: "https://blog.boot.dev/main.css", Kind: LinkStylesheet, StatusCode: 200, ContentType: "text/css", ContentEncoding: "gzip", Size: 8 * 1024, CacheControl: "public, max-age=86400"},
+			"https://blog.boot.dev/gone.woff2": {URL: "https://blog.boot.dev/gone.woff2", Kind: LinkFont, StatusCode: 404},
+		},
+		Mu: &sync.Mutex{},
+	}
+
+	report := cfg.buildAssetReport(200 * 1024)
+
+	if expected := []string{"https://blog.boot.dev/gone.woff2"}; !reflect.DeepEqual(report.Broken, expected) {
+		t.Errorf("Broken FAIL: expected: %v, actual: %v", expected, report.Broken)
+	}
+	if expected := []string{"https://blog.boot.dev/big.jpg"}; !reflect.DeepEqual(report.OversizedImages, expected) {
+		t.Errorf("OversizedImages FAIL: expected: %v, actual: %v", expected, report.OversizedImages)
+	}
+	if expected := []string{"https://blog.boot.dev/app.js"}; !reflect.DeepEqual(report.Uncompressed, expected) {
+		t.Errorf("Uncompressed FAIL: expected: %v, actual: %v", expected, report.Uncompressed)
+	}
+	if expected := []string{"https://blog.boot.dev/app.js"}; !reflect.DeepEqual(report.Uncached, expected) {
+		t.Errorf("Uncached FAIL: expected: %v, actual: %v", expected, report.Uncached)
+	}
+}
@@ -0,0 +1,255 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Auth holds the credentials the crawler may send. Neither an Authorization
+// header nor any ClientOptions.Headers are sent to a host that AllowHost
+// rejects, so other sites never see them.
+type Auth struct {
+	Username     string
+	Password     string
+	BearerTokens []BearerToken
+	AllowHost    func(host string) bool
+}
+
+// BearerToken is sent as "Authorization: Bearer <Token>" to hosts matching
+// Host, which may be "*.example.com" for a domain and its subdomains.
+type BearerToken struct {
+	Host  string
+	Token string
+}
+
+// ParseBearerToken parses a "host=token" pair.
+func ParseBearerToken(raw string) (BearerToken, error) {
+	host, token, ok := strings.Cut(raw, "=")
+	host, token = strings.TrimSpace(host), strings.TrimSpace(token)
+	if !ok || host == "" || token == "" {
+		return BearerToken{}, fmt.Errorf("couldn't parse bearer token '%s': expected 'host=token'", raw)
+	}
+	return BearerToken{Host: host, Token: token}, nil
+}
+
+func (auth *Auth) bearerToken(host string) (string, bool) {
+	for _, bearer := range auth.BearerTokens {
+		if matchesHost(bearer.Host, host) {
+			return bearer.Token, true
+		}
+	}
+	return "", false
+}
+
+// authChallenge is what a host asked for in its WWW-Authenticate header.
+type authChallenge struct {
+	scheme     string
+	params     map[string]string
+	nonceCount int
+}
+
+// authTransport adds bearer tokens up front and answers basic and digest
+// challenges, remembering each host's challenge so later requests are
+// authenticated without another round trip.
+type authTransport struct {
+	auth       *Auth
+	base       http.RoundTripper
+	mu         sync.Mutex
+	challenges map[string]*authChallenge
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if t.auth.AllowHost == nil || !t.auth.AllowHost(host) {
+		// also drops an Authorization header set through ClientOptions.Headers
+		if req.Header.Get("Authorization") != "" {
+			req = req.Clone(req.Context())
+			req.Header.Del("Authorization")
+		}
+		return t.base.RoundTrip(req)
+	}
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	if token, ok := t.auth.bearerToken(host); ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+		return t.base.RoundTrip(req)
+	}
+	if t.auth.Username == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	res, err := t.base.RoundTrip(t.withAuthorization(req))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// first request to this host, or its challenge changed (e.g. a stale
+	// digest nonce): learn the new challenge and answer it once
+	if !t.learnChallenge(host, res.Header.Values("WWW-Authenticate")) {
+		return res, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return res, nil
+		}
+		if retry.Body, err = req.GetBody(); err != nil {
+			return res, nil
+		}
+	}
+	res.Body.Close()
+	return t.base.RoundTrip(t.withAuthorization(retry))
+}
+
+// withAuthorization returns req with an Authorization header answering the
+// host's known challenge, or req itself if there isn't one.
+func (t *authTransport) withAuthorization(req *http.Request) *http.Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	challenge, ok := t.challenges[req.URL.Hostname()]
+	if !ok {
+		return req
+	}
+
+	authorized := req.Clone(req.Context())
+	switch challenge.scheme {
+	case "basic":
+		authorized.SetBasicAuth(t.auth.Username, t.auth.Password)
+	case "digest":
+		challenge.nonceCount++
+		authorized.Header.Set("Authorization", challenge.digestAuthorization(req.Method, req.URL.RequestURI(), t.auth.Username, t.auth.Password))
+	}
+	return authorized
+}
+
+// learnChallenge records the strongest supported challenge among the
+// WWW-Authenticate headers, preferring digest over basic.
+func (t *authTransport) learnChallenge(host string, headers []string) bool {
+	var learned *authChallenge
+	for _, header := range headers {
+		scheme, params := parseAuthChallenge(header)
+		switch {
+		case scheme == "digest" && digestHash(params["algorithm"]) != nil && params["nonce"] != "":
+			learned = &authChallenge{scheme: scheme, params: params}
+		case scheme == "basic" && learned == nil:
+			learned = &authChallenge{scheme: scheme, params: params}
+		}
+	}
+	if learned == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.challenges == nil {
+		t.challenges = map[string]*authChallenge{}
+	}
+	t.challenges[host] = learned
+	return true
+}
+
+// parseAuthChallenge splits a WWW-Authenticate value such as
+// `Digest realm="x", qop="auth", nonce="abc"` into its scheme and parameters.
+func parseAuthChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end == -1 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return strings.ToLower(scheme), params
+}
+
+// digestHash returns the hash for a digest algorithm (RFC 7616), or nil if
+// it isn't supported.
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5", "MD5-SESS":
+		return md5.New
+	case "SHA-256", "SHA-256-SESS":
+		return sha256.New
+	}
+	return nil
+}
+
+func (challenge *authChallenge) digestAuthorization(method, uri, username, password string) string {
+	newHash := digestHash(challenge.params["algorithm"])
+	h := func(s string) string {
+		hasher := newHash()
+		hasher.Write([]byte(s))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	realm, nonce := challenge.params["realm"], challenge.params["nonce"]
+	cnonceBytes := make([]byte, 8)
+	rand.Read(cnonceBytes)
+	cnonce := hex.EncodeToString(cnonceBytes)
+	nc := fmt.Sprintf("%08x", challenge.nonceCount)
+
+	ha1 := h(username + ":" + realm + ":" + password)
+	if strings.HasSuffix(strings.ToUpper(challenge.params["algorithm"]), "-SESS") {
+		ha1 = h(ha1 + ":" + nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	qop := ""
+	for _, option := range strings.Split(challenge.params["qop"], ",") {
+		if strings.TrimSpace(option) == "auth" {
+			qop = "auth"
+		}
+	}
+
+	var response string
+	if qop == "" {
+		response = h(ha1 + ":" + nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, realm),
+		fmt.Sprintf(`nonce="%s"`, nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if algorithm := challenge.params["algorithm"]; algorithm != "" {
+		parts = append(parts, "algorithm="+algorithm)
+	}
+	if opaque := challenge.params["opaque"]; opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, opaque))
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAuthTransport(t *testing.T) {
+	const username, password, realm, nonce = "crawler", "s3cret", "staging", "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+
+	var lastAuthorization string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/basic", func(w http.ResponseWriter, r *http.Request) {
+		lastAuthorization = r.Header.Get("Authorization")
+		if user, pass, ok := r.BasicAuth(); !ok || user != username || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="staging"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	mux.HandleFunc("/digest", func(w http.ResponseWriter, r *http.Request) {
+		lastAuthorization = r.Header.Get("Authorization")
+		scheme, params := parseAuthChallenge(lastAuthorization)
+		ha1 := md5Hex(username + ":" + realm + ":" + password)
+		ha2 := md5Hex(r.Method + ":" + params["uri"])
+		expected := md5Hex(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+		if scheme != "digest" || params["response"] != expected || params["uri"] != r.URL.RequestURI() {
+			w.Header().Add("WWW-Authenticate", `Basic realm="staging"`)
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth,auth-int", nonce="%s", opaque="5ccc069c403ebaf9f0171e9517f40e41"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	mux.HandleFunc("/bearer", func(w http.ResponseWriter, r *http.Request) {
+		lastAuthorization = r.Header.Get("Authorization")
+		if lastAuthorization != "Bearer t0k3n" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name                  string
+		auth                  Auth
+		path                  string
+		expectedStatus        int
+		expectedAuthorization bool
+	}{
+		{
+			name:                  "basic challenge answered",
+			auth:                  Auth{Username: username, Password: password},
+			path:                  "/basic?page=1",
+			expectedStatus:        200,
+			expectedAuthorization: true,
+		},
+		{
+			name:                  "digest preferred over basic",
+			auth:                  Auth{Username: username, Password: password},
+			path:                  "/digest?page=1",
+			expectedStatus:        200,
+			expectedAuthorization: true,
+		},
+		{
+			name:           "wrong password",
+			auth:           Auth{Username: username, Password: "wrong"},
+			path:           "/basic",
+			expectedStatus: 401,
+		},
+		{
+			name:                  "bearer token for matching host",
+			auth:                  Auth{BearerTokens: []BearerToken{{Host: "127.0.0.1", Token: "t0k3n"}}},
+			path:                  "/bearer",
+			expectedStatus:        200,
+			expectedAuthorization: true,
+		},
+		{
+			name:           "bearer token for another host",
+			auth:           Auth{BearerTokens: []BearerToken{{Host: "*.boot.dev", Token: "t0k3n"}}},
+			path:           "/bearer",
+			expectedStatus: 401,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.auth.AllowHost = func(host string) bool { return true }
+			client := NewHTTPClient(ClientOptions{Auth: &tc.auth})
+
+			// the second request reuses the challenge learned from the first
+			for attempt := 0; attempt < 2; attempt++ {
+				res, err := client.Get(server.URL + tc.path)
+				if err != nil {
+					t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+					return
+				}
+				res.Body.Close()
+
+				if res.StatusCode != tc.expectedStatus {
+					t.Errorf("Test %v - %s FAIL: expected status %d, actual: %d", i, tc.name, tc.expectedStatus, res.StatusCode)
+				}
+				if tc.expectedAuthorization && lastAuthorization == "" {
+					t.Errorf("Test %v - %s FAIL: expected an Authorization header on attempt %d", i, tc.name, attempt+1)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthTransportOutOfScope(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("WWW-Authenticate", `Basic realm="external"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	headers, _ := ParseHeaders([]string{"Authorization: Bearer from-header"})
+	client := NewHTTPClient(ClientOptions{
+		Headers: headers,
+		Auth: &Auth{
+			Username:     "crawler",
+			Password:     "s3cret",
+			BearerTokens: []BearerToken{{Host: "127.0.0.1", Token: "t0k3n"}},
+			AllowHost:    func(host string) bool { return false },
+		},
+	})
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res.Body.Close()
+
+	if authorization != "" {
+		t.Errorf("expected no credentials for an out-of-scope host, got: %s", authorization)
+	}
+}
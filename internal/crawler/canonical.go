@@ -0,0 +1,321 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// canonicalFromHeader returns the first rel="canonical" target sent in an
+// HTTP Link header, e.g. Link: <https://example.com/a>; rel="canonical"
+func canonicalFromHeader(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range parseLinkHeader(value) {
+			if hasRelToken(link.params["rel"], "canonical") {
+				return link.url
+			}
+		}
+	}
+	return ""
+}
+
+// effectiveCanonical is the canonical search engines are most likely to use:
+// the first <link rel="canonical">, falling back to the Link header.
+func effectiveCanonical(data *PageData) string {
+	if data.Canonical != "" {
+		return data.Canonical
+	}
+	return data.HeaderCanonical
+}
+
+// resolveCanonical resolves a canonical href against the page it was found
+// on and normalizes it so it can be looked up in cfg.Pages.
+func resolveCanonical(normalizer URLNormalizer, pageRawURL, href string) (resolved, normalized string, ok bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || pageRawURL == "" {
+		return "", "", false
+	}
+	pageURL, err := url.Parse(pageRawURL)
+	if err != nil {
+		return "", "", false
+	}
+	canonicalURL, err := url.Parse(href)
+	if err != nil {
+		return "", "", false
+	}
+
+	resolved = pageURL.ResolveReference(canonicalURL).String()
+	normalized, err = normalizer.Normalize(resolved)
+	if err != nil {
+		return "", "", false
+	}
+	return resolved, normalized, true
+}
+
+type CanonicalIssue struct {
+	URL        string `json:"url"`
+	Canonical  string `json:"canonical"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// CanonicalChain is a canonical that points at a page which is itself
+// canonicalised elsewhere. Chain starts with the page's own canonical.
+type CanonicalChain struct {
+	URL   string   `json:"url"`
+	Chain []string `json:"chain"`
+	Loop  bool     `json:"loop,omitempty"`
+}
+
+type CanonicalCluster struct {
+	Canonical string   `json:"canonical"`
+	Pages     []string `json:"pages"`
+}
+
+// CanonicalAudit checks every page's canonical against the rest of the crawl.
+type CanonicalAudit struct {
+	PagesChecked       int                `json:"pages_checked"`
+	PagesWithCanonical int                `json:"pages_with_canonical"`
+	SelfReferencing    int                `json:"self_referencing"`
+	Invalid            []CanonicalIssue   `json:"invalid"`
+	Conflicting        []CanonicalIssue   `json:"conflicting"`
+	HeaderMismatches   []CanonicalIssue   `json:"header_mismatches"`
+	OutOfScope         []CanonicalIssue   `json:"out_of_scope"`
+	BadTargets         []CanonicalIssue   `json:"bad_targets"`
+	Chains             []CanonicalChain   `json:"chains"`
+	Clusters           []CanonicalCluster `json:"clusters"`
+}
+
+// maxCanonicalChain stops following canonicals that never settle.
+const maxCanonicalChain = 10
+
+// AuditCanonicals must be called after the crawl has finished. In-scope
+// canonical targets that weren't crawled get a lightweight status check.
+func (cfg *Config) AuditCanonicals() *CanonicalAudit {
+	audit := &CanonicalAudit{
+		Invalid:          []CanonicalIssue{},
+		Conflicting:      []CanonicalIssue{},
+		HeaderMismatches: []CanonicalIssue{},
+		OutOfScope:       []CanonicalIssue{},
+		BadTargets:       []CanonicalIssue{},
+		Chains:           []CanonicalChain{},
+		Clusters:         []CanonicalCluster{},
+	}
+	clusters := map[string]*CanonicalCluster{}
+	unchecked := map[string][]string{} // canonical URL -> pages pointing at it
+
+	cfg.Mu.Lock()
+	for pageKey, data := range cfg.Pages {
+		if data.StatusCode != http.StatusOK || data.FinalURL != "" {
+			continue
+		}
+		audit.PagesChecked++
+		href := effectiveCanonical(data)
+		if href == "" {
+			continue
+		}
+		audit.PagesWithCanonical++
+
+		resolved, targetKey, ok := resolveCanonical(cfg.Normalizer, data.URL, href)
+		if !ok {
+			audit.Invalid = append(audit.Invalid, CanonicalIssue{URL: data.URL, Canonical: href, Message: "couldn't be resolved"})
+			continue
+		}
+		if others := cfg.otherCanonicals(data, targetKey); len(others) > 0 {
+			audit.Conflicting = append(audit.Conflicting, CanonicalIssue{
+				URL:       data.URL,
+				Canonical: resolved,
+				Message:   "also declares " + strings.Join(others, ", "),
+			})
+		}
+		if data.Canonical != "" && data.HeaderCanonical != "" {
+			headerResolved, headerKey, ok := resolveCanonical(cfg.Normalizer, data.URL, data.HeaderCanonical)
+			if !ok || headerKey != targetKey {
+				if !ok {
+					headerResolved = data.HeaderCanonical
+				}
+				audit.HeaderMismatches = append(audit.HeaderMismatches, CanonicalIssue{
+					URL:       data.URL,
+					Canonical: resolved,
+					Message:   "Link header declares " + headerResolved,
+				})
+			}
+		}
+
+		if targetKey == pageKey {
+			audit.SelfReferencing++
+			continue
+		}
+		cluster, ok := clusters[targetKey]
+		if !ok {
+			cluster = &CanonicalCluster{Canonical: resolved}
+			clusters[targetKey] = cluster
+		} else if resolved < cluster.Canonical {
+			cluster.Canonical = resolved
+		}
+		cluster.Pages = append(cluster.Pages, data.URL)
+
+		if !cfg.inScope(resolved) {
+			audit.OutOfScope = append(audit.OutOfScope, CanonicalIssue{URL: data.URL, Canonical: resolved})
+			continue
+		}
+		target, crawled := cfg.Pages[targetKey]
+		if !crawled || target.StatusCode == 0 {
+			unchecked[resolved] = append(unchecked[resolved], data.URL)
+			continue
+		}
+		if issue, bad := canonicalTargetProblem(resolved, target); bad {
+			issue.URL, issue.Canonical = data.URL, resolved
+			audit.BadTargets = append(audit.BadTargets, issue)
+			continue
+		}
+		if chain, loop := cfg.canonicalChain(pageKey, resolved, targetKey); len(chain) > 1 {
+			audit.Chains = append(audit.Chains, CanonicalChain{URL: data.URL, Chain: chain, Loop: loop})
+		}
+	}
+	cfg.Mu.Unlock()
+
+	rawURLs := make([]string, 0, len(unchecked))
+	for rawURL := range unchecked {
+		rawURLs = append(rawURLs, rawURL)
+	}
+	for _, result := range cfg.checkURLStatuses(rawURLs) {
+		message := fmt.Sprintf("returns %d", result.StatusCode)
+		if result.Location != "" {
+			message = "redirects to " + result.Location
+		}
+		for _, pageURL := range unchecked[result.URL] {
+			audit.BadTargets = append(audit.BadTargets, CanonicalIssue{
+				URL:        pageURL,
+				Canonical:  result.URL,
+				StatusCode: result.StatusCode,
+				Message:    message,
+			})
+		}
+	}
+
+	for _, cluster := range clusters {
+		if len(cluster.Pages) < 2 {
+			continue
+		}
+		sort.Strings(cluster.Pages)
+		audit.Clusters = append(audit.Clusters, *cluster)
+	}
+	sort.Slice(audit.Clusters, func(i, j int) bool {
+		if len(audit.Clusters[i].Pages) != len(audit.Clusters[j].Pages) {
+			return len(audit.Clusters[i].Pages) > len(audit.Clusters[j].Pages)
+		}
+		return audit.Clusters[i].Canonical < audit.Clusters[j].Canonical
+	})
+	for _, issues := range [][]CanonicalIssue{audit.Invalid, audit.Conflicting, audit.HeaderMismatches, audit.OutOfScope, audit.BadTargets} {
+		sortCanonicalIssues(issues)
+	}
+	sort.Slice(audit.Chains, func(i, j int) bool {
+		return audit.Chains[i].URL < audit.Chains[j].URL
+	})
+	return audit
+}
+
+// otherCanonicals lists the <link rel="canonical"> targets that disagree
+// with the one in use. Search engines may ignore all of them when they do.
+func (cfg *Config) otherCanonicals(data *PageData, targetKey string) []string {
+	var others []string
+	seen := map[string]bool{targetKey: true}
+	for _, href := range data.Canonicals {
+		resolved, key, ok := resolveCanonical(cfg.Normalizer, data.URL, href)
+		if !ok {
+			resolved, key = href, href
+		}
+		if !seen[key] {
+			seen[key] = true
+			others = append(others, resolved)
+		}
+	}
+	return others
+}
+
+// canonicalChain follows canonicals from targetKey through crawled pages
+// until one is self-canonical or the chain returns to a page already seen.
+func (cfg *Config) canonicalChain(pageKey, resolved, targetKey string) (chain []string, loop bool) {
+	chain = []string{resolved}
+	seen := map[string]bool{pageKey: true, targetKey: true}
+	for len(chain) < maxCanonicalChain {
+		target, crawled := cfg.Pages[targetKey]
+		if !crawled || target.StatusCode != http.StatusOK || target.FinalURL != "" {
+			return chain, false
+		}
+		next, nextKey, ok := resolveCanonical(cfg.Normalizer, target.URL, effectiveCanonical(target))
+		if !ok || nextKey == targetKey {
+			return chain, false
+		}
+		chain = append(chain, next)
+		if seen[nextKey] {
+			return chain, true
+		}
+		seen[nextKey] = true
+		targetKey = nextKey
+	}
+	return chain, false
+}
+
+// canonicalTargetProblem reports crawled canonical targets search engines
+// won't accept: redirects, errors and noindexed pages.
+func canonicalTargetProblem(canonical string, target *PageData) (CanonicalIssue, bool) {
+	hops := redirectHopsFrom(canonical, target)
+	switch {
+	case len(hops) > 0:
+		return CanonicalIssue{StatusCode: hops[0].StatusCode, Message: "redirects to " + hops[len(hops)-1].Location}, true
+	case target.StatusCode != http.StatusOK:
+		return CanonicalIssue{StatusCode: target.StatusCode, Message: fmt.Sprintf("returns %d", target.StatusCode)}, true
+	case isNoindex(target):
+		return CanonicalIssue{StatusCode: target.StatusCode, Message: "noindexed"}, true
+	}
+	return CanonicalIssue{}, false
+}
+
+func sortCanonicalIssues(issues []CanonicalIssue) {
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].URL < issues[j].URL
+	})
+}
+
+func (audit *CanonicalAudit) writeText(w io.Writer) {
+	writeSectionHeader(w, "CANONICALS")
+	fmt.Fprintf(w, "%d of %d pages declare a canonical, %d of them self-referencing\n", audit.PagesWithCanonical, audit.PagesChecked, audit.SelfReferencing)
+
+	writeIssues := func(title string, issues []CanonicalIssue) {
+		fmt.Fprintf(w, "\n%s: %d\n", title, len(issues))
+		for _, issue := range issues {
+			if issue.Message != "" {
+				fmt.Fprintf(w, "  - %s -> %s: %s\n", issue.URL, issue.Canonical, issue.Message)
+			} else {
+				fmt.Fprintf(w, "  - %s -> %s\n", issue.URL, issue.Canonical)
+			}
+		}
+	}
+	writeIssues("Canonicals that couldn't be resolved", audit.Invalid)
+	writeIssues("Pages with conflicting canonical tags", audit.Conflicting)
+	writeIssues("Link header and HTML canonicals that disagree", audit.HeaderMismatches)
+	writeIssues("Canonicals outside the crawl scope", audit.OutOfScope)
+	writeIssues("Canonicals pointing to redirects, errors or noindexed pages", audit.BadTargets)
+
+	fmt.Fprintf(w, "\nCanonical chains: %d\n", len(audit.Chains))
+	for _, chain := range audit.Chains {
+		suffix := ""
+		if chain.Loop {
+			suffix = " (loop)"
+		}
+		fmt.Fprintf(w, "  - %s -> %s%s\n", chain.URL, strings.Join(chain.Chain, " -> "), suffix)
+	}
+
+	fmt.Fprintf(w, "\nPages canonicalised to the same URL: %d\n", len(audit.Clusters))
+	for _, cluster := range audit.Clusters {
+		fmt.Fprintf(w, "  - %s (%d pages)\n", cluster.Canonical, len(cluster.Pages))
+		for _, u := range cluster.Pages {
+			fmt.Fprintf(w, "      %s\n", u)
+		}
+	}
+}
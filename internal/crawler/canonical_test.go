@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCanonicalFromHeader(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://blog.boot.dev/de/>; rel="alternate"; hreflang="de"`)
+	header.Add("Link", `</style.css>; rel=preload, <https://blog.boot.dev/a>; rel="canonical"`)
+
+	if actual := canonicalFromHeader(header); actual != "https://blog.boot.dev/a" {
+		t.Errorf("expected https://blog.boot.dev/a, actual: %q", actual)
+	}
+}
+
+func TestAuditCanonicals(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/uncrawled-gone", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/uncrawled-moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/uncrawled-ok", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	cfg := &Config{
+		Normalizer:         DefaultURLNormalizer(),
+		Scope:              Scope{AllowedHosts: []string{serverURL.Hostname()}},
+		HTTPClient:         NewHTTPClient(DefaultClientOptions()),
+		Mu:                 &sync.Mutex{},
+		ConcurrencyControl: make(chan struct{}, 2),
+		Pages:              map[string]*PageData{},
+	}
+	addPage := func(path string, data PageData) {
+		data.URL = server.URL + path
+		if data.StatusCode == 0 {
+			data.StatusCode = 200
+		}
+		key, err := cfg.Normalizer.Normalize(data.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cfg.Pages[key] = &data
+	}
+	withCanonical := func(hrefs ...string) PageMetadata {
+		return PageMetadata{Canonical: hrefs[0], Canonicals: hrefs}
+	}
+
+	addPage("/self", PageData{PageMetadata: withCanonical("/self")})
+	addPage("/a", PageData{PageMetadata: withCanonical("b")})
+	addPage("/b", PageData{PageMetadata: withCanonical("/c")})
+	addPage("/c", PageData{PageMetadata: withCanonical("/c")})
+	addPage("/conflict", PageData{PageMetadata: withCanonical("/c", "/c/", "/self")})
+	addPage("/header", PageData{PageMetadata: withCanonical("/c"), HeaderCanonical: "/self"})
+	addPage("/header-only", PageData{HeaderCanonical: "/c"})
+	addPage("/external", PageData{PageMetadata: withCanonical("https://example.com/")})
+	addPage("/to-noindex", PageData{PageMetadata: withCanonical("/noindex")})
+	addPage("/noindex", PageData{PageMetadata: PageMetadata{Robots: "noindex"}})
+	addPage("/to-old", PageData{PageMetadata: withCanonical("/old")})
+	addPage("/old", PageData{StatusCode: 200, FinalURL: server.URL + "/c", Redirects: []RedirectHop{{URL: server.URL + "/old", StatusCode: 301, Location: server.URL + "/c"}}})
+	addPage("/to-gone", PageData{PageMetadata: withCanonical("/uncrawled-gone")})
+	addPage("/to-moved", PageData{PageMetadata: withCanonical("/uncrawled-moved")})
+	addPage("/to-ok", PageData{PageMetadata: withCanonical("/uncrawled-ok")})
+	addPage("/loop-x", PageData{PageMetadata: withCanonical("/loop-y")})
+	addPage("/loop-y", PageData{PageMetadata: withCanonical("/loop-x")})
+	addPage("/missing", PageData{StatusCode: 404})
+
+	audit := cfg.AuditCanonicals()
+	if audit.PagesChecked != 16 || audit.PagesWithCanonical != 15 || audit.SelfReferencing != 2 {
+		t.Errorf("expected 16 checked, 15 with canonical, 2 self-referencing, actual: %d, %d, %d",
+			audit.PagesChecked, audit.PagesWithCanonical, audit.SelfReferencing)
+	}
+
+	type check struct {
+		name     string
+		issues   []CanonicalIssue
+		expected []string // "path -> message"
+	}
+	for _, c := range []check{
+		{"conflicting", audit.Conflicting, []string{"/conflict -> also declares " + server.URL + "/self"}},
+		{"header mismatches", audit.HeaderMismatches, []string{"/header -> Link header declares " + server.URL + "/self"}},
+		{"out of scope", audit.OutOfScope, []string{"/external -> "}},
+		{"bad targets", audit.BadTargets, []string{"/to-gone -> returns 404", "/to-moved -> redirects to " + server.URL + "/c", "/to-noindex -> noindexed", "/to-old -> redirects to " + server.URL + "/c"}},
+	} {
+		var actual []string
+		for _, issue := range c.issues {
+			actual = append(actual, issue.URL[len(server.URL):]+" -> "+issue.Message)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected: %v, actual: %+v", c.name, c.expected, c.issues)
+		}
+	}
+
+	expectedChains := []CanonicalChain{
+		{URL: server.URL + "/a", Chain: []string{server.URL + "/b", server.URL + "/c"}},
+		{URL: server.URL + "/loop-x", Chain: []string{server.URL + "/loop-y", server.URL + "/loop-x"}, Loop: true},
+		{URL: server.URL + "/loop-y", Chain: []string{server.URL + "/loop-x", server.URL + "/loop-y"}, Loop: true},
+	}
+	if !reflect.DeepEqual(audit.Chains, expectedChains) {
+		t.Errorf("expected chains: %+v, actual: %+v", expectedChains, audit.Chains)
+	}
+
+	expectedClusters := []CanonicalCluster{{
+		Canonical: server.URL + "/c",
+		Pages:     []string{server.URL + "/b", server.URL + "/conflict", server.URL + "/header", server.URL + "/header-only"},
+	}}
+	if !reflect.DeepEqual(audit.Clusters, expectedClusters) {
+		t.Errorf("expected clusters: %+v, actual: %+v", expectedClusters, audit.Clusters)
+	}
+}
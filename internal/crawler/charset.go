@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// metaPrescanSize is how far into the document browsers look for a
+// <meta charset> declaration.
+const metaPrescanSize = 1024
+
+// pageCharset records how a page declared its encoding and which encoding
+// was actually used to decode it.
+type pageCharset struct {
+	Header   string
+	Meta     string
+	Detected string
+	Mismatch string
+}
+
+// detectCharset picks the encoding to decode body with the way browsers do:
+// byte order mark, then the Content-Type charset, then <meta>, then a guess.
+func detectCharset(body []byte, contentType string) (encoding.Encoding, pageCharset) {
+	info := pageCharset{Meta: metaCharset(body)}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		info.Header = params["charset"]
+	}
+
+	enc, name, _ := charset.DetermineEncoding(body, contentType)
+	info.Detected = name
+	info.Mismatch = charsetMismatch(body, info)
+	return enc, info
+}
+
+// charsetMismatch describes disagreements between the declared charsets and
+// the bytes actually served, or returns "" when they're consistent.
+func charsetMismatch(body []byte, info pageCharset) string {
+	var problems []string
+
+	headerName := canonicalCharset(info.Header)
+	metaName := canonicalCharset(info.Meta)
+	if info.Header != "" && headerName == "" {
+		problems = append(problems, fmt.Sprintf("unknown Content-Type charset %q", info.Header))
+	}
+	if info.Meta != "" && metaName == "" {
+		problems = append(problems, fmt.Sprintf("unknown <meta> charset %q", info.Meta))
+	}
+	if headerName != "" && metaName != "" && headerName != metaName {
+		problems = append(problems, fmt.Sprintf("Content-Type says %s but <meta> says %s", headerName, metaName))
+	}
+
+	declared := headerName
+	if declared == "" {
+		declared = metaName
+	}
+	if declared != "" && declared != info.Detected {
+		problems = append(problems, fmt.Sprintf("declared %s but decoded as %s", declared, info.Detected))
+	}
+	if info.Detected == "utf-8" && !utf8.Valid(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))) {
+		problems = append(problems, "decoded as utf-8 but the body isn't valid UTF-8")
+	}
+
+	return strings.Join(problems, "; ")
+}
+
+// canonicalCharset returns the WHATWG name for a charset label, or "" if the
+// label is unknown.
+func canonicalCharset(label string) string {
+	if label == "" {
+		return ""
+	}
+	_, name := charset.Lookup(label)
+	return name
+}
+
+// metaCharset returns the charset declared by <meta charset> or
+// <meta http-equiv="Content-Type"> near the start of the document.
+func metaCharset(body []byte) string {
+	if len(body) > metaPrescanSize {
+		body = body[:metaPrescanSize]
+	}
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data != "meta" {
+				continue
+			}
+
+			var httpEquiv, content string
+			for _, attr := range token.Attr {
+				switch attr.Key {
+				case "charset":
+					return strings.TrimSpace(attr.Val)
+				case "http-equiv":
+					httpEquiv = attr.Val
+				case "content":
+					content = attr.Val
+				}
+			}
+			if strings.EqualFold(httpEquiv, "content-type") {
+				if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+					return params["charset"]
+				}
+			}
+		}
+	}
+}
+
+// writeCharsetMismatchesText lists pages whose declared and detected
+// encodings disagree; nothing is written when every page is consistent.
+func writeCharsetMismatchesText(w io.Writer, pages []Page) {
+	var mismatched []Page
+	for _, page := range pages {
+		if page.CharsetMismatch != "" {
+			mismatched = append(mismatched, page)
+		}
+	}
+	if len(mismatched) == 0 {
+		return
+	}
+
+	writeSectionHeader(w, "CHARSET MISMATCHES")
+	for _, page := range mismatched {
+		fmt.Fprintf(w, "  - %s: %s\n", page.URL, page.CharsetMismatch)
+	}
+}
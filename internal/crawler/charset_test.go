@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		contentType      string
+		expectedTitle    string
+		expectedCharset  pageCharset
+		expectedMismatch bool
+	}{
+		{
+			name:            "utf-8 header",
+			body:            "<html><head><title>café</title></head></html>",
+			contentType:     "text/html; charset=utf-8",
+			expectedTitle:   "café",
+			expectedCharset: pageCharset{Header: "utf-8", Detected: "utf-8"},
+		},
+		{
+			name:            "windows-1252 from meta",
+			body:            "<html><head><meta charset=\"windows-1252\"><title>caf\xe9</title></head></html>",
+			contentType:     "text/html",
+			expectedTitle:   "café",
+			expectedCharset: pageCharset{Meta: "windows-1252", Detected: "windows-1252"},
+		},
+		{
+			name:            "shift_jis from http-equiv",
+			body:            "<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=Shift_JIS\"><title>\x93\xfa\x96\x7b</title></head></html>",
+			contentType:     "text/html",
+			expectedTitle:   "日本",
+			expectedCharset: pageCharset{Meta: "Shift_JIS", Detected: "shift_jis"},
+		},
+		{
+			name:            "iso-8859-1 label maps to windows-1252",
+			body:            "<html><head><title>\xfcber</title></head></html>",
+			contentType:     "text/html; charset=ISO-8859-1",
+			expectedTitle:   "über",
+			expectedCharset: pageCharset{Header: "ISO-8859-1", Detected: "windows-1252"},
+		},
+		{
+			name:             "header overrides conflicting meta",
+			body:             "<html><head><meta charset=\"utf-8\"><title>caf\xe9</title></head></html>",
+			contentType:      "text/html; charset=windows-1252",
+			expectedTitle:    "café",
+			expectedCharset:  pageCharset{Header: "windows-1252", Meta: "utf-8", Detected: "windows-1252"},
+			expectedMismatch: true,
+		},
+		{
+			name:             "byte order mark overrides header",
+			body:             "\xef\xbb\xbf<html><head><title>café</title></head></html>",
+			contentType:      "text/html; charset=windows-1252",
+			expectedTitle:    "café",
+			expectedCharset:  pageCharset{Header: "windows-1252", Detected: "utf-8"},
+			expectedMismatch: true,
+		},
+		{
+			name:             "invalid utf-8 declared as utf-8",
+			body:             "<html><head><title>caf\xe9</title></head></html>",
+			contentType:      "text/html; charset=utf-8",
+			expectedTitle:    "caf�",
+			expectedCharset:  pageCharset{Header: "utf-8", Detected: "utf-8"},
+			expectedMismatch: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, info := detectCharset([]byte(tc.body), tc.contentType)
+			body, err := enc.NewDecoder().String(tc.body)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+
+			if !strings.Contains(body, "<title>"+tc.expectedTitle+"</title>") {
+				t.Errorf("Test %v - %s FAIL: expected title %q in decoded body: %q", i, tc.name, tc.expectedTitle, body)
+			}
+			if (info.Mismatch != "") != tc.expectedMismatch {
+				t.Errorf("Test %v - %s FAIL: expected mismatch: %v, actual: %q", i, tc.name, tc.expectedMismatch, info.Mismatch)
+			}
+			info.Mismatch = ""
+			if info != tc.expectedCharset {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expectedCharset, info)
+			}
+		})
+	}
+}
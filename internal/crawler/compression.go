@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding is sent on page and asset requests. Setting it ourselves
+// stops net/http from decompressing transparently, so the transferred size
+// can be measured before decoding.
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decodeContentEncoding undoes a Content-Encoding header, which may list
+// several codings in the order they were applied.
+func decodeContentEncoding(contentEncoding string, body io.Reader) (io.ReadCloser, error) {
+	codings := strings.Split(contentEncoding, ",")
+	decoded := io.NopCloser(body)
+	var closers []io.Closer
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		var reader io.ReadCloser
+		switch coding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(decoded)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't decode gzip body: %v", err)
+			}
+			reader = gz
+		case "deflate":
+			reader = newDeflateReader(decoded)
+		case "br":
+			reader = io.NopCloser(brotli.NewReader(decoded))
+		case "zstd":
+			zr, err := zstd.NewReader(decoded)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't decode zstd body: %v", err)
+			}
+			reader = zr.IOReadCloser()
+		default:
+			return nil, fmt.Errorf("unsupported Content-Encoding %q", coding)
+		}
+		closers = append(closers, reader)
+		decoded = reader
+	}
+	return &multiCloser{Reader: decoded, closers: closers}, nil
+}
+
+// newDeflateReader handles "deflate" bodies, which should be zlib-wrapped
+// but are sent as raw DEFLATE by some servers.
+func newDeflateReader(body io.Reader) io.ReadCloser {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(buffered); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(buffered)
+}
+
+// multiCloser closes every decoder stacked by decodeContentEncoding.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	for _, closer := range m.closers {
+		closer.Close()
+	}
+	return nil
+}
+
+// writeUncompressedPagesText lists pages big enough to benefit from
+// compression that were sent without any; nothing is written otherwise.
+func writeUncompressedPagesText(w io.Writer, pages []Page) {
+	var uncompressed []Page
+	for _, page := range pages {
+		if page.ContentEncoding == "" && page.DecodedSize >= minCompressibleSize {
+			uncompressed = append(uncompressed, page)
+		}
+	}
+	if len(uncompressed) == 0 {
+		return
+	}
+
+	writeSectionHeader(w, "UNCOMPRESSED PAGES")
+	for _, page := range uncompressed {
+		fmt.Fprintf(w, "  - %s (%d bytes)\n", page.URL, page.DecodedSize)
+	}
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func compress(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		w = zw
+	default:
+		return data
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	page := []byte("<html><body>" + strings.Repeat("<p>compress me</p>", 200) + "</body></html>")
+
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+		expectError     bool
+	}{
+		{name: "identity", contentEncoding: "", body: page},
+		{name: "gzip", contentEncoding: "gzip", body: compress(t, "gzip", page)},
+		{name: "zlib deflate", contentEncoding: "deflate", body: compress(t, "deflate", page)},
+		{name: "raw deflate", contentEncoding: "deflate", body: compress(t, "raw-deflate", page)},
+		{name: "brotli", contentEncoding: "br", body: compress(t, "br", page)},
+		{name: "zstd", contentEncoding: "zstd", body: compress(t, "zstd", page)},
+		{name: "stacked codings", contentEncoding: "gzip, br", body: compress(t, "br", compress(t, "gzip", page))},
+		{name: "unknown coding", contentEncoding: "compress", body: page, expectError: true},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := decodeContentEncoding(tc.contentEncoding, bytes.NewReader(tc.body))
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Test %v - '%s' FAIL: expected error, got none", i, tc.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			defer body.Close()
+
+			decoded, err := io.ReadAll(body)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected read error: %v", i, tc.name, err)
+				return
+			}
+			if !bytes.Equal(decoded, page) {
+				t.Errorf("Test %v - %s FAIL: expected %d decoded bytes, actual: %d", i, tc.name, len(page), len(decoded))
+			}
+		})
+	}
+}
+
+func TestGetHTMLTransferSize(t *testing.T) {
+	page := []byte("<html><head><title>Sizes</title></head><body>" + strings.Repeat("<p>compress me</p>", 200) + "</body></html>")
+	compressed := compress(t, "br", page)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "br") {
+			w.Write(page)
+			return
+		}
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(compressed)
+	}))
+	defer server.Close()
+
+	cfg := &Config{UserAgent: "Crawler", HTTPClient: NewHTTPClient(DefaultClientOptions()), MaxBodySize: DefaultMaxBodySize}
+	htmlRes, err := cfg.getHTML(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if htmlRes.ContentEncoding != "br" {
+		t.Errorf("expected content encoding br, actual: %q", htmlRes.ContentEncoding)
+	}
+	if htmlRes.TransferSize != int64(len(compressed)) {
+		t.Errorf("expected transfer size %d, actual: %d", len(compressed), htmlRes.TransferSize)
+	}
+	if htmlRes.DecodedSize != int64(len(page)) {
+		t.Errorf("expected decoded size %d, actual: %d", len(page), htmlRes.DecodedSize)
+	}
+}
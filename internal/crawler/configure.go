@@ -2,28 +2,38 @@ package crawler
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"slices"
 	"sync"
 	"time"
 )
 
 type PageData struct {
-	LinkCount    int
-	Title        string
-	Description  string
-	Keywords     string
-	Author       string
-	Canonical    string
-	Language     string
-	Charset      string
-	OGImage      string
-	OGType       string
-	OGURL        string
-	OGSiteName   string
-	TwitterCard  string
-	TwitterSite  string
-	TwitterImage string
-	Suggestions  *AnalysisResult
+	PageMetadata
+	URL               string
+	LinkCount         int
+	StatusCode        int
+	ContentType       string
+	FinalURL          string
+	Redirects         []RedirectHop
+	LastModified      string
+	Truncated         bool
+	FromCache         bool
+	ContentEncoding   string
+	TransferSize      int64
+	DecodedSize       int64
+	HeaderCharset     string
+	DetectedCharset   string
+	CharsetMismatch   string
+	XRobotsTag        string
+	HeaderCanonical   string
+	Proxy             string
+	SitemapLastMod    string
+	SitemapChangeFreq string
+	SitemapPriority   string
+	Assets            []Link
+	Suggestions       *AnalysisResult
 }
 
 type Config struct {
@@ -33,14 +43,37 @@ type Config struct {
 	ConcurrencyControl chan struct{}
 	WG                 *sync.WaitGroup
 	MaxPages           int
-	Robots             *RobotsChecker
+	RobotsByHost       map[string]*RobotsChecker
+	HTTPClient         *http.Client
 	RateLimit          time.Duration
 	UserAgent          string
 	JSONOutput         bool
 	Analyzer           *AIAnalyzer
+	SitemapURLs        map[string]*SitemapURL
+	Inlinks            map[string]*Inlink
+	ExternalLinks      map[string]*ExternalLink
+	Normalizer         URLNormalizer
+	Scope              Scope
+	MaxBodySize        int64
+	CollectAssets      bool
+	Assets             map[string]*Asset
+	Extractors         *ExtractorRegistry
 }
 
-func (cfg *Config) addPageVisit(normalizedURL string) (isFirst bool) {
+// DefaultMaxBodySize is the most of a page that is read and parsed; anything
+// beyond it is dropped and the page marked as truncated.
+const DefaultMaxBodySize = 10 * 1024 * 1024
+
+// maxInlinkReferrers caps how many referring pages are kept per target;
+// the total is still counted.
+const maxInlinkReferrers = 10
+
+type Inlink struct {
+	Count     int
+	Referrers []string
+}
+
+func (cfg *Config) addPageVisit(normalizedURL, rawURL string) (isFirst bool) {
 	cfg.Mu.Lock()
 	defer cfg.Mu.Unlock()
 
@@ -49,10 +82,30 @@ func (cfg *Config) addPageVisit(normalizedURL string) (isFirst bool) {
 		return false
 	}
 
-	cfg.Pages[normalizedURL] = &PageData{LinkCount: 1}
+	cfg.Pages[normalizedURL] = &PageData{URL: rawURL, LinkCount: 1}
 	return true
 }
 
+func (cfg *Config) addInlink(fromNormalizedURL, fromRawURL, rawTargetURL string) {
+	targetURL, err := cfg.Normalizer.Normalize(rawTargetURL)
+	if err != nil || targetURL == fromNormalizedURL {
+		return
+	}
+
+	cfg.Mu.Lock()
+	defer cfg.Mu.Unlock()
+
+	inlink, ok := cfg.Inlinks[targetURL]
+	if !ok {
+		inlink = &Inlink{}
+		cfg.Inlinks[targetURL] = inlink
+	}
+	inlink.Count++
+	if len(inlink.Referrers) < maxInlinkReferrers && !slices.Contains(inlink.Referrers, fromRawURL) {
+		inlink.Referrers = append(inlink.Referrers, fromRawURL)
+	}
+}
+
 func (cfg *Config) PagesLen() int {
 	cfg.Mu.Lock()
 	defer cfg.Mu.Unlock()
@@ -77,10 +130,19 @@ func Configure(rawBaseURL string, maxConcurrency int, maxPages int, rateLimit ti
 		ConcurrencyControl: make(chan struct{}, maxConcurrency),
 		WG:                 &sync.WaitGroup{},
 		MaxPages:           maxPages,
-		Robots:             NewRobotsChecker(baseURL, userAgent),
+		RobotsByHost:       make(map[string]*RobotsChecker),
+		HTTPClient:         NewHTTPClient(DefaultClientOptions()),
 		RateLimit:          rateLimit,
 		UserAgent:          userAgent,
 		JSONOutput:         jsonOutput,
 		Analyzer:           analyzer,
+		SitemapURLs:        make(map[string]*SitemapURL),
+		Inlinks:            make(map[string]*Inlink),
+		ExternalLinks:      make(map[string]*ExternalLink),
+		Normalizer:         DefaultURLNormalizer(),
+		Scope:              Scope{AllowedHosts: []string{baseURL.Hostname()}},
+		MaxBodySize:        DefaultMaxBodySize,
+		Assets:             make(map[string]*Asset),
+		Extractors:         DefaultExtractors(),
 	}, nil
 }
@@ -0,0 +1,63 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// nonContentElements hold text that isn't part of the visible page copy.
+var nonContentElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true,
+}
+
+// extractContent records the headings, images without alt text and word
+// count of the page body for the on-page rules.
+func extractContent(doc *html.Node, meta *PageMetadata) {
+	walkElements(doc, func(n *html.Node) {
+		switch n.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			meta.Headings = append(meta.Headings, Heading{
+				Level: int(n.Data[1] - '0'),
+				Text:  strings.Join(strings.Fields(nodeText(n)), " "),
+			})
+		case "img":
+			meta.ImageCount++
+			// alt="" is correct for decorative images, only a missing attribute is a problem
+			if !hasAttr(n, "alt") {
+				meta.ImagesWithoutAlt = append(meta.ImagesWithoutAlt, getAttr(n, "src"))
+			}
+		}
+	})
+	meta.WordCount = countWords(doc)
+}
+
+func countWords(n *html.Node) int {
+	if n.Type == html.ElementNode && nonContentElements[n.Data] {
+		return 0
+	}
+	if n.Type == html.TextNode {
+		return len(strings.Fields(n.Data))
+	}
+	count := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		count += countWords(c)
+	}
+	return count
+}
+
+// H1s returns the text of the page's <h1> headings.
+func (m *PageMetadata) H1s() []string {
+	var h1s []string
+	for _, heading := range m.Headings {
+		if heading.Level == 1 {
+			h1s = append(h1s, heading.Text)
+		}
+	}
+	return h1s
+}
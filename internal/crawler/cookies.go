@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// NewCookieJar returns a jar that keeps cookies set by crawled sites for the
+// rest of the crawl, refusing cookies scoped to public suffixes like .co.uk.
+func NewCookieJar() (http.CookieJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create cookie jar: %v", err)
+	}
+	return jar, nil
+}
+
+// LoadCookiesFile adds the cookies in a Netscape cookies.txt file, as
+// exported by browsers and written by curl, to jar.
+func LoadCookiesFile(path string, jar http.CookieJar) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("couldn't open cookies file: %v", err)
+	}
+	defer file.Close()
+
+	return loadCookies(file, jar, time.Now())
+}
+
+func loadCookies(r io.Reader, jar http.CookieJar, now time.Time) error {
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		// curl marks HttpOnly cookies with a prefix that otherwise looks
+		// like a comment
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("couldn't parse cookies file line %d: expected 7 tab-separated fields, got %d", lineNumber, len(fields))
+		}
+		domain, includeSubdomains, path, secure, expires, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return fmt.Errorf("couldn't parse cookies file line %d: invalid expiry '%s'", lineNumber, expires)
+		}
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     path,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expiresUnix != 0 {
+			cookie.Expires = time.Unix(expiresUnix, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+		// without a Domain the jar treats the cookie as host-only
+		if strings.EqualFold(includeSubdomains, "TRUE") {
+			cookie.Domain = domain
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: strings.TrimPrefix(domain, "."), Path: path}, []*http.Cookie{cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("couldn't read cookies file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadCookies(t *testing.T) {
+	cookiesTxt := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"",
+		"staging.boot.dev\tFALSE\t/\tFALSE\t0\tpreview\tabc123",
+		".boot.dev\tTRUE\t/\tTRUE\t4102444800\tlocale\ten-GB",
+		"#HttpOnly_staging.boot.dev\tFALSE\t/admin\tFALSE\t0\tsession\txyz",
+		"staging.boot.dev\tFALSE\t/\tFALSE\t946684800\texpired\told",
+	}, "\n")
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jar, err := NewCookieJar()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loadCookies(strings.NewReader(cookiesTxt), jar, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		inputURL string
+		expected []string
+	}{
+		{
+			name:     "host-only cookie on its host",
+			inputURL: "http://staging.boot.dev/",
+			expected: []string{"preview=abc123"},
+		},
+		{
+			name:     "secure domain cookie over https",
+			inputURL: "https://staging.boot.dev/",
+			expected: []string{"locale=en-GB", "preview=abc123"},
+		},
+		{
+			name:     "domain cookie on a sibling subdomain",
+			inputURL: "https://www.boot.dev/",
+			expected: []string{"locale=en-GB"},
+		},
+		{
+			name:     "path-scoped HttpOnly cookie",
+			inputURL: "http://staging.boot.dev/admin/users",
+			expected: []string{"preview=abc123", "session=xyz"},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inputURL, _ := url.Parse(tc.inputURL)
+			var actual []string
+			for _, cookie := range jar.Cookies(inputURL) {
+				actual = append(actual, cookie.Name+"="+cookie.Value)
+			}
+			sort.Strings(actual)
+			if strings.Join(actual, "; ") != strings.Join(tc.expected, "; ") {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadCookiesInvalidLine(t *testing.T) {
+	jar, _ := NewCookieJar()
+	err := loadCookies(strings.NewReader("boot.dev TRUE / FALSE 0 name value"), jar, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected an error for line 1, got: %v", err)
+	}
+}
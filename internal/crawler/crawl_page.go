@@ -3,10 +3,7 @@ package crawler
 import (
 	"fmt"
 	"net/url"
-	"strings"
 	"time"
-
-	"golang.org/x/net/html"
 )
 
 func (cfg *Config) CrawlPage(rawCurrentURL string) {
@@ -26,23 +23,23 @@ func (cfg *Config) CrawlPage(rawCurrentURL string) {
 		return
 	}
 
-	// skip other websites
-	if currentURL.Hostname() != cfg.BaseURL.Hostname() {
+	// skip URLs outside the configured scope
+	if !cfg.Scope.Allows(currentURL) {
 		return
 	}
 
 	// Check robots.txt
-	if !cfg.Robots.IsAllowed(rawCurrentURL) {
+	if !cfg.robotsAllowed(currentURL) {
 		return
 	}
 
-	normalizedURL, err := normalizeURL(rawCurrentURL)
+	normalizedURL, err := cfg.Normalizer.Normalize(rawCurrentURL)
 	if err != nil {
 		fmt.Printf("Error - normalizedURL: %v", err)
 		return
 	}
 
-	isFirst := cfg.addPageVisit(normalizedURL)
+	isFirst := cfg.addPageVisit(normalizedURL, rawCurrentURL)
 	if !isFirst {
 		return
 	}
@@ -52,34 +49,87 @@ func (cfg *Config) CrawlPage(rawCurrentURL string) {
 	// Rate limiting
 	time.Sleep(cfg.RateLimit)
 
-	htmlBody, err := cfg.getHTML(rawCurrentURL)
+	htmlRes, err := cfg.getHTML(rawCurrentURL)
+	if htmlRes != nil {
+		cfg.Mu.Lock()
+		if data, ok := cfg.Pages[normalizedURL]; ok {
+			data.StatusCode = htmlRes.StatusCode
+			data.ContentType = htmlRes.ContentType
+			data.FinalURL = htmlRes.FinalURL
+			data.Redirects = htmlRes.Redirects
+			if len(htmlRes.Redirects) > 0 {
+				data.StatusCode = htmlRes.Redirects[0].StatusCode
+			}
+		}
+		cfg.Mu.Unlock()
+	}
 	if err != nil {
 		fmt.Printf("Error - getHTML: %v", err)
 		return
 	}
 
+	// Store redirected pages under the URL the server sent us to
+	pageURL, pageKey := rawCurrentURL, normalizedURL
+	if htmlRes.FinalURL != "" {
+		finalURL, err := url.Parse(htmlRes.FinalURL)
+		if err != nil || !cfg.Scope.Allows(finalURL) {
+			return
+		}
+		finalKey, err := cfg.Normalizer.Normalize(htmlRes.FinalURL)
+		if err != nil {
+			fmt.Printf("Error - normalizedURL: %v", err)
+			return
+		}
+
+		if finalKey == normalizedURL {
+			// the redirect only changed something normalisation ignores
+			cfg.Mu.Lock()
+			if data, ok := cfg.Pages[normalizedURL]; ok {
+				data.URL = htmlRes.FinalURL
+				data.FinalURL = ""
+			}
+			cfg.Mu.Unlock()
+		} else if !cfg.addPageVisit(finalKey, htmlRes.FinalURL) {
+			return
+		}
+		pageURL, pageKey = htmlRes.FinalURL, finalKey
+	}
+
+	cfg.Mu.Lock()
+	if data, ok := cfg.Pages[pageKey]; ok {
+		data.StatusCode = htmlRes.StatusCode
+		data.ContentType = htmlRes.ContentType
+		data.XRobotsTag = htmlRes.Header.Get("X-Robots-Tag")
+		data.HeaderCanonical = canonicalFromHeader(htmlRes.Header)
+		data.LastModified = htmlRes.Header.Get("Last-Modified")
+		data.Truncated = htmlRes.Truncated
+		data.FromCache = htmlRes.FromCache
+		data.ContentEncoding = htmlRes.ContentEncoding
+		data.TransferSize = htmlRes.TransferSize
+		data.DecodedSize = htmlRes.DecodedSize
+		data.Proxy = htmlRes.Proxy
+		data.HeaderCharset = htmlRes.Charset.Header
+		data.DetectedCharset = htmlRes.Charset.Detected
+		data.CharsetMismatch = htmlRes.Charset.Mismatch
+	}
+	cfg.Mu.Unlock()
+
 	// Extract metadata
-	title, description, keywords, author, canonical, language, charset, ogImage, ogType, ogURL, ogSiteName, twitterCard, twitterSite, twitterImage := extractMetadata(htmlBody)
+	metadata := cfg.extractMetadata(htmlRes.Doc)
+	metadata.Hreflang = append(metadata.Hreflang, hreflangFromHeader(htmlRes.Header)...)
 	cfg.Mu.Lock()
-	if data, ok := cfg.Pages[normalizedURL]; ok {
-		data.Title = title
-		data.Description = description
-		data.Keywords = keywords
-		data.Author = author
-		data.Canonical = canonical
-		data.Language = language
-		data.Charset = charset
-		data.OGImage = ogImage
-		data.OGType = ogType
-		data.OGURL = ogURL
-		data.OGSiteName = ogSiteName
-		data.TwitterCard = twitterCard
-		data.TwitterSite = twitterSite
-		data.TwitterImage = twitterImage
+	if data, ok := cfg.Pages[pageKey]; ok {
+		data.PageMetadata = metadata
+
+		if entry, ok := cfg.SitemapURLs[pageKey]; ok {
+			data.SitemapLastMod = entry.LastMod
+			data.SitemapChangeFreq = entry.ChangeFreq
+			data.SitemapPriority = entry.Priority
+		}
 
 		// AI Analysis if enabled
 		if cfg.Analyzer != nil {
-			analysis, err := cfg.Analyzer.AnalyzePage(rawCurrentURL, title, description)
+			analysis, err := cfg.Analyzer.AnalyzePage(pageURL, metadata.Title, metadata.Description)
 			if err != nil {
 				fmt.Printf("Warning - AI analysis failed: %v\n", err)
 			} else {
@@ -89,159 +139,27 @@ func (cfg *Config) CrawlPage(rawCurrentURL string) {
 	}
 	cfg.Mu.Unlock()
 
-	nextURLs, err := getURLsFromHTML(htmlBody, cfg.BaseURL)
-	if err != nil {
-		fmt.Printf("Error - getURLsFromHTML: %v", err)
-		return
-	}
-
-	for _, nextURL := range nextURLs {
-		cfg.WG.Add(1)
-		go cfg.CrawlPage(nextURL)
-	}
-}
-
-func extractMetadata(htmlBody string) (title, description, keywords, author, canonical, language, charset, ogImage, ogType, ogURL, ogSiteName, twitterCard, twitterSite, twitterImage string) {
-	doc, err := html.Parse(strings.NewReader(htmlBody))
+	parsedPageURL, err := url.Parse(pageURL)
 	if err != nil {
+		fmt.Printf("Error - crawlPage: couldn't parse URL '%s': %v\n", pageURL, err)
 		return
 	}
 
-	var (
-		ogDescription      string
-		twitterDescription string
-		jsonLDDescription  string
-	)
-
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		// Extract language from html tag
-		if n.Type == html.ElementNode && n.Data == "html" {
-			for _, a := range n.Attr {
-				if a.Key == "lang" {
-					language = a.Val
-				}
+	for _, link := range extractLinks(htmlRes.Doc, parsedPageURL) {
+		if link.Kind.IsAsset() {
+			if cfg.CollectAssets {
+				cfg.addPageAsset(pageKey, pageURL, link)
 			}
+			continue
 		}
 
-		if n.Type == html.ElementNode && n.Data == "title" && n.FirstChild != nil {
-			title = n.FirstChild.Data
-		}
-
-		if n.Type == html.ElementNode && n.Data == "link" {
-			var rel, href string
-			for _, a := range n.Attr {
-				if a.Key == "rel" {
-					rel = a.Val
-				}
-				if a.Key == "href" {
-					href = a.Val
-				}
-			}
-			if rel == "canonical" {
-				canonical = href
-			}
-		}
-
-		if n.Type == html.ElementNode && n.Data == "meta" {
-			var name, property, content, charsetAttr string
-			for _, a := range n.Attr {
-				if a.Key == "name" {
-					name = a.Val
-				}
-				if a.Key == "property" {
-					property = a.Val
-				}
-				if a.Key == "content" {
-					content = a.Val
-				}
-				if a.Key == "charset" {
-					charsetAttr = a.Val
-				}
-			}
-
-			// Charset
-			if charsetAttr != "" {
-				charset = charsetAttr
-			}
-
-			// Standard meta tags
-			if name == "description" {
-				description = content
-			} else if name == "keywords" {
-				keywords = content
-			} else if name == "author" {
-				author = content
-			}
-
-			// Open Graph
-			if property == "og:description" {
-				ogDescription = content
-			} else if property == "og:image" {
-				ogImage = content
-			} else if property == "og:type" {
-				ogType = content
-			} else if property == "og:url" {
-				ogURL = content
-			} else if property == "og:site_name" {
-				ogSiteName = content
-			}
-
-			// Twitter Cards
-			if name == "twitter:description" {
-				twitterDescription = content
-			} else if name == "twitter:card" {
-				twitterCard = content
-			} else if name == "twitter:site" {
-				twitterSite = content
-			} else if name == "twitter:image" {
-				twitterImage = content
-			}
-		}
-
-		if n.Type == html.ElementNode && n.Data == "script" {
-			isJSONLD := false
-			for _, a := range n.Attr {
-				if a.Key == "type" && a.Val == "application/ld+json" {
-					isJSONLD = true
-					break
-				}
-			}
-			if isJSONLD && n.FirstChild != nil {
-				jsonContent := n.FirstChild.Data
-				// Basic string extraction to avoid full JSON parsing overhead/complexity for now
-				// Looking for "description": "..."
-				if idx := strings.Index(jsonContent, `"description":`); idx != -1 {
-					start := idx + 14
-					rest := jsonContent[start:]
-					rest = strings.TrimSpace(rest)
-					if strings.HasPrefix(rest, `"`) {
-						rest = rest[1:]
-						if end := strings.Index(rest, `",`); end != -1 {
-							jsonLDDescription = rest[:end]
-						} else if end := strings.Index(rest, `"`+"\n"); end != -1 { // Handle end of line
-							jsonLDDescription = rest[:end]
-						}
-					}
-				}
-			}
-		}
-
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(doc)
-
-	if description == "" {
-		if ogDescription != "" {
-			description = ogDescription
-		} else if twitterDescription != "" {
-			description = twitterDescription
-		} else if jsonLDDescription != "" {
-			description = jsonLDDescription
+		nextURL := link.URL
+		cfg.addInlink(pageKey, pageURL, nextURL)
+		if !cfg.inScope(nextURL) {
+			cfg.addExternalLink(pageURL, nextURL)
+			continue
 		}
+		cfg.WG.Add(1)
+		go cfg.CrawlPage(nextURL)
 	}
-
-	return
 }
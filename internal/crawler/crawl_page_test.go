@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCrawlPageRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>New</title></head><body></body></html>`))
+	})
+	mux.HandleFunc("/About", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/about", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>About</title></head><body></body></html>`))
+	})
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/", http.StatusFound)
+	})
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Docs</title></head><body></body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	type expectedPage struct {
+		URL        string
+		StatusCode int
+		FinalURL   string
+		Hops       int
+		LinkCount  int
+		Title      string
+	}
+
+	tests := []struct {
+		name     string
+		crawl    []string
+		expected map[string]expectedPage // path -> page stored under its key
+	}{
+		{
+			name:  "redirect to an already-crawled page",
+			crawl: []string{"/new", "/old"},
+			expected: map[string]expectedPage{
+				"/new": {URL: server.URL + "/new", StatusCode: 200, LinkCount: 2, Title: "New"},
+				"/old": {URL: server.URL + "/old", StatusCode: 301, FinalURL: server.URL + "/new", Hops: 1, LinkCount: 1},
+			},
+		},
+		{
+			name:  "redirect that only changes case",
+			crawl: []string{"/About"},
+			expected: map[string]expectedPage{
+				"/about": {URL: server.URL + "/about", StatusCode: 200, Hops: 1, LinkCount: 1, Title: "About"},
+			},
+		},
+		{
+			name:  "redirect that only adds a trailing slash",
+			crawl: []string{"/docs"},
+			expected: map[string]expectedPage{
+				"/docs": {URL: server.URL + "/docs/", StatusCode: 200, Hops: 1, LinkCount: 1, Title: "Docs"},
+			},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := Configure(server.URL, 1, 10, time.Duration(0), "Crawler", false, "", "")
+			if err != nil {
+				t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+			}
+			for _, path := range tc.crawl {
+				cfg.WG.Add(1)
+				cfg.CrawlPage(server.URL + path)
+			}
+
+			actual := map[string]expectedPage{}
+			for _, data := range cfg.Pages {
+				actual[data.URL] = expectedPage{
+					URL:        data.URL,
+					StatusCode: data.StatusCode,
+					FinalURL:   data.FinalURL,
+					Hops:       len(data.Redirects),
+					LinkCount:  data.LinkCount,
+					Title:      data.Title,
+				}
+			}
+			if len(cfg.Pages) != len(tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected %d pages, actual: %+v", i, tc.name, len(tc.expected), actual)
+			}
+			for path, expected := range tc.expected {
+				key, _ := cfg.Normalizer.Normalize(server.URL + path)
+				data, ok := cfg.Pages[key]
+				if !ok {
+					t.Errorf("Test %v - %s FAIL: expected a page stored under %s", i, tc.name, key)
+					continue
+				}
+				if actual := actual[data.URL]; actual != expected {
+					t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, expected, actual)
+				}
+				// a reference to the URL that redirected names the target, while
+				// the page a same-key redirect ended on is a valid target
+				if len(data.Redirects) > 0 {
+					hop := data.Redirects[0]
+					issue, bad := canonicalTargetProblem(hop.URL, data)
+					if want := "redirects to " + hop.Location; !bad || issue.Message != want {
+						t.Errorf("Test %v - %s FAIL: expected: %q, actual: %q", i, tc.name, want, issue.Message)
+					}
+				}
+				if data.FinalURL == "" {
+					if issue, bad := canonicalTargetProblem(data.URL, data); bad {
+						t.Errorf("Test %v - %s FAIL: expected no issue for %s, actual: %q", i, tc.name, data.URL, issue.Message)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DuplicateGroup is a set of pages sharing the same text once case and
+// whitespace are folded. Exact is false when the pages' raw text differs.
+type DuplicateGroup struct {
+	Text  string   `json:"text"`
+	Exact bool     `json:"exact"`
+	Pages []string `json:"pages"`
+}
+
+// DuplicateContent groups pages with the same title, meta description or
+// H1, largest groups first.
+type DuplicateContent struct {
+	PagesChecked int              `json:"pages_checked"`
+	Titles       []DuplicateGroup `json:"titles"`
+	Descriptions []DuplicateGroup `json:"descriptions"`
+	H1s          []DuplicateGroup `json:"h1s"`
+}
+
+func FindDuplicateContent(pages []Page) *DuplicateContent {
+	titles, descriptions, h1s := duplicateIndex{}, duplicateIndex{}, duplicateIndex{}
+
+	checked := 0
+	for _, page := range pages {
+		if !isHTMLPage(page.StatusCode, page.FinalURL, page.ContentType) {
+			continue
+		}
+		checked++
+		titles.add(page.Title, page.URL)
+		descriptions.add(page.MetaDescription, page.URL)
+		for _, h1 := range page.H1s() {
+			h1s.add(h1, page.URL)
+		}
+	}
+
+	return &DuplicateContent{
+		PagesChecked: checked,
+		Titles:       titles.groups(),
+		Descriptions: descriptions.groups(),
+		H1s:          h1s.groups(),
+	}
+}
+
+type duplicateEntry struct {
+	variants map[string]int // raw text -> pages using it
+	pages    []string
+}
+
+type duplicateIndex map[string]*duplicateEntry
+
+func (index duplicateIndex) add(text, pageURL string) {
+	key := normalizeText(text)
+	if key == "" {
+		return
+	}
+	entry, ok := index[key]
+	if !ok {
+		entry = &duplicateEntry{variants: map[string]int{}}
+		index[key] = entry
+	}
+	// a page repeating the same H1 still counts once
+	if n := len(entry.pages); n > 0 && entry.pages[n-1] == pageURL {
+		return
+	}
+	entry.variants[text]++
+	entry.pages = append(entry.pages, pageURL)
+}
+
+// groups returns the entries shared by more than one page. The text shown is
+// the most common raw variant.
+func (index duplicateIndex) groups() []DuplicateGroup {
+	groups := []DuplicateGroup{}
+	for _, entry := range index {
+		if len(entry.pages) < 2 {
+			continue
+		}
+		text, uses := "", 0
+		for variant, n := range entry.variants {
+			if n > uses || (n == uses && variant < text) {
+				text, uses = variant, n
+			}
+		}
+		sort.Strings(entry.pages)
+		groups = append(groups, DuplicateGroup{Text: text, Exact: len(entry.variants) == 1, Pages: entry.pages})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Pages) != len(groups[j].Pages) {
+			return len(groups[i].Pages) > len(groups[j].Pages)
+		}
+		return groups[i].Text < groups[j].Text
+	})
+	return groups
+}
+
+func (duplicates *DuplicateContent) writeText(w io.Writer) {
+	writeSectionHeader(w, "DUPLICATE TITLES, DESCRIPTIONS AND H1S")
+	fmt.Fprintf(w, "%d pages checked\n", duplicates.PagesChecked)
+
+	writeGroups := func(title string, groups []DuplicateGroup) {
+		fmt.Fprintf(w, "\n%s: %d\n", title, len(groups))
+		for _, group := range groups {
+			suffix := ""
+			if !group.Exact {
+				suffix = ", differing in case or whitespace"
+			}
+			fmt.Fprintf(w, "  - %q (%d pages%s)\n", group.Text, len(group.Pages), suffix)
+			for _, u := range group.Pages {
+				fmt.Fprintf(w, "      %s\n", u)
+			}
+		}
+	}
+	writeGroups("Duplicate titles", duplicates.Titles)
+	writeGroups("Duplicate meta descriptions", duplicates.Descriptions)
+	writeGroups("Duplicate H1s", duplicates.H1s)
+}
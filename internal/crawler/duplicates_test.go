@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicateContent(t *testing.T) {
+	page := func(url, title, description string, h1s ...string) Page {
+		meta := PageMetadata{Title: title, Description: description, MetaDescription: description}
+		for _, h1 := range h1s {
+			meta.Headings = append(meta.Headings, Heading{Level: 1, Text: h1})
+		}
+		return Page{URL: url, StatusCode: 200, ContentType: "text/html", PageMetadata: meta}
+	}
+	pages := []Page{
+		page("https://blog.boot.dev/a", "Boot.dev Blog", "Learn backend", "Posts"),
+		page("https://blog.boot.dev/b", "Boot.dev Blog", "Learn backend", "Posts", "Posts"),
+		page("https://blog.boot.dev/c", "boot.dev  blog ", "Something else", "About"),
+		page("https://blog.boot.dev/d", "Unique", "", "About"),
+		page("https://blog.boot.dev/e", "Other", ""),
+		// a shared og:description is only a fallback, not a duplicate meta description
+		{URL: "https://blog.boot.dev/og-a", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{Title: "OG A", Description: "Shared social text"}},
+		{URL: "https://blog.boot.dev/og-b", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{Title: "OG B", Description: "Shared social text"}},
+		{URL: "https://blog.boot.dev/gone", StatusCode: 404, PageMetadata: PageMetadata{Title: "Unique"}},
+		{URL: "https://blog.boot.dev/doc.pdf", StatusCode: 200, ContentType: "application/pdf", PageMetadata: PageMetadata{Title: "Unique"}},
+	}
+
+	expected := &DuplicateContent{
+		PagesChecked: 7,
+		Titles: []DuplicateGroup{
+			{Text: "Boot.dev Blog", Exact: false, Pages: []string{"https://blog.boot.dev/a", "https://blog.boot.dev/b", "https://blog.boot.dev/c"}},
+		},
+		Descriptions: []DuplicateGroup{
+			{Text: "Learn backend", Exact: true, Pages: []string{"https://blog.boot.dev/a", "https://blog.boot.dev/b"}},
+		},
+		H1s: []DuplicateGroup{
+			{Text: "About", Exact: true, Pages: []string{"https://blog.boot.dev/c", "https://blog.boot.dev/d"}},
+			{Text: "Posts", Exact: true, Pages: []string{"https://blog.boot.dev/a", "https://blog.boot.dev/b"}},
+		},
+	}
+	if actual := FindDuplicateContent(pages); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, actual)
+	}
+}
@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRedirects matches the limit used by net/http's default client.
+const maxRedirects = 10
+
+type ExternalLink struct {
+	URL        string   `json:"url"`
+	StatusCode int      `json:"status_code,omitempty"`
+	FinalURL   string   `json:"final_url,omitempty"`
+	Redirects  int      `json:"redirects,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Count      int      `json:"count"`
+	Referrers  []string `json:"referrers"`
+}
+
+func (link *ExternalLink) isBroken() bool {
+	return link.Error != "" || link.StatusCode >= 400
+}
+
+// addExternalLink records an http(s) link outside the crawl scope so it can
+// be checked once the crawl has finished. That covers other hosts as well as
+// sections of allowed hosts left out by path prefixes or patterns.
+func (cfg *Config) addExternalLink(fromRawURL, rawTargetURL string) {
+	targetURL, err := url.Parse(rawTargetURL)
+	if err != nil || (targetURL.Scheme != "http" && targetURL.Scheme != "https") {
+		return
+	}
+	if cfg.Scope.Allows(targetURL) {
+		return
+	}
+	targetURL.Fragment = ""
+	key := targetURL.String()
+
+	cfg.Mu.Lock()
+	defer cfg.Mu.Unlock()
+
+	link, ok := cfg.ExternalLinks[key]
+	if !ok {
+		link = &ExternalLink{URL: key, Referrers: []string{}}
+		cfg.ExternalLinks[key] = link
+	}
+	link.Count++
+	if len(link.Referrers) < maxInlinkReferrers && !slices.Contains(link.Referrers, fromRawURL) {
+		link.Referrers = append(link.Referrers, fromRawURL)
+	}
+}
+
+// CheckExternalLinks validates every collected external link without
+// crawling it, using its own concurrency limit and delay between requests.
+func (cfg *Config) CheckExternalLinks(maxConcurrency int, rateLimit time.Duration) []ExternalLink {
+	cfg.Mu.Lock()
+	links := make([]*ExternalLink, 0, len(cfg.ExternalLinks))
+	for _, link := range cfg.ExternalLinks {
+		links = append(links, link)
+	}
+	cfg.Mu.Unlock()
+
+	concurrencyControl := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+	for _, link := range links {
+		wg.Add(1)
+		go func(link *ExternalLink) {
+			concurrencyControl <- struct{}{}
+			defer func() {
+				<-concurrencyControl
+				wg.Done()
+			}()
+
+			time.Sleep(rateLimit)
+			cfg.checkExternalLink(link)
+		}(link)
+	}
+	wg.Wait()
+
+	results := make([]ExternalLink, 0, len(links))
+	for _, link := range links {
+		results = append(results, *link)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].isBroken() != results[j].isBroken() {
+			return results[i].isBroken()
+		}
+		return results[i].URL < results[j].URL
+	})
+	return results
+}
+
+// checkExternalLink follows redirects by hand so the hop count is known,
+// trying a cheap HEAD first and falling back to GET for servers that reject it.
+func (cfg *Config) checkExternalLink(link *ExternalLink) {
+	currentURL := link.URL
+	for hops := 0; ; hops++ {
+		statusCode, location, err := cfg.checkURLStatus("HEAD", currentURL)
+		if err != nil || statusCode >= 400 {
+			statusCode, location, err = cfg.checkURLStatus("GET", currentURL)
+		}
+		if err != nil {
+			link.Error = err.Error()
+			return
+		}
+
+		link.StatusCode = statusCode
+		if location == "" || statusCode < 300 || statusCode > 399 {
+			if hops > 0 {
+				link.FinalURL = currentURL
+				link.Redirects = hops
+			}
+			return
+		}
+		if hops == maxRedirects {
+			link.Error = fmt.Sprintf("stopped after %d redirects", maxRedirects)
+			return
+		}
+		currentURL = location
+	}
+}
+
+func writeExternalLinksText(w io.Writer, links []ExternalLink) {
+	writeSectionHeader(w, "EXTERNAL LINKS")
+	fmt.Fprintf(w, "%d external links checked\n", len(links))
+
+	var broken, redirected []ExternalLink
+	for _, link := range links {
+		if link.isBroken() {
+			broken = append(broken, link)
+		} else if link.Redirects > 0 {
+			redirected = append(redirected, link)
+		}
+	}
+
+	fmt.Fprintf(w, "\nBroken external links: %d\n", len(broken))
+	for _, link := range broken {
+		status := link.Error
+		if status == "" {
+			status = fmt.Sprint(link.StatusCode)
+		}
+		fmt.Fprintf(w, "  - %s: %s (linked from %s)\n", link.URL, status, strings.Join(link.Referrers, ", "))
+	}
+
+	fmt.Fprintf(w, "\nRedirected external links: %d\n", len(redirected))
+	for _, link := range redirected {
+		fmt.Fprintf(w, "  - %s -> %s (%d redirects, status %d)\n", link.URL, link.FinalURL, link.Redirects, link.StatusCode)
+	}
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCheckExternalLink(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved-again", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-again", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name              string
+		path              string
+		expectedStatus    int
+		expectedFinalURL  string
+		expectedRedirects int
+		expectedBroken    bool
+	}{
+		{
+			name:           "ok",
+			path:           "/ok",
+			expectedStatus: 200,
+		},
+		{
+			name:           "not found",
+			path:           "/missing",
+			expectedStatus: 404,
+			expectedBroken: true,
+		},
+		{
+			name:           "HEAD rejected falls back to GET",
+			path:           "/no-head",
+			expectedStatus: 200,
+		},
+		{
+			name:              "redirect chain",
+			path:              "/moved",
+			expectedStatus:    200,
+			expectedFinalURL:  server.URL + "/ok",
+			expectedRedirects: 2,
+		},
+		{
+			name:           "redirect loop",
+			path:           "/loop",
+			expectedStatus: 302,
+			expectedBroken: true,
+		},
+	}
+
+	cfg := &Config{UserAgent: "Crawler", HTTPClient: NewHTTPClient(DefaultClientOptions())}
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			link := &ExternalLink{URL: server.URL + tc.path}
+			cfg.checkExternalLink(link)
+
+			if link.StatusCode != tc.expectedStatus {
+				t.Errorf("Test %v - %s FAIL: expected status %d, actual: %d", i, tc.name, tc.expectedStatus, link.StatusCode)
+			}
+			if link.FinalURL != tc.expectedFinalURL {
+				t.Errorf("Test %v - %s FAIL: expected final URL %s, actual: %s", i, tc.name, tc.expectedFinalURL, link.FinalURL)
+			}
+			if link.Redirects != tc.expectedRedirects {
+				t.Errorf("Test %v - %s FAIL: expected %d redirects, actual: %d", i, tc.name, tc.expectedRedirects, link.Redirects)
+			}
+			if link.isBroken() != tc.expectedBroken {
+				t.Errorf("Test %v - %s FAIL: expected broken %v, actual: %v (%s)", i, tc.name, tc.expectedBroken, link.isBroken(), link.Error)
+			}
+		})
+	}
+}
+
+func TestAddExternalLink(t *testing.T) {
+	cfg := &Config{
+		Mu:            &sync.Mutex{},
+		ExternalLinks: map[string]*ExternalLink{},
+		Scope:         Scope{AllowedHosts: []string{"blog.boot.dev"}, PathPrefixes: []string{"/posts/"}},
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		expected bool
+	}{
+		{name: "other host", target: "https://example.com/a#top", expected: true},
+		{name: "section outside the path prefixes", target: "https://blog.boot.dev/docs/missing", expected: true},
+		{name: "in scope", target: "https://blog.boot.dev/posts/a"},
+		{name: "not http", target: "mailto:team@boot.dev"},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg.addExternalLink("https://blog.boot.dev/posts/", tc.target)
+			key := strings.TrimSuffix(tc.target, "#top")
+			if _, recorded := cfg.ExternalLinks[key]; recorded != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected recorded: %v, actual: %v", i, tc.name, tc.expected, recorded)
+			}
+		})
+	}
+}
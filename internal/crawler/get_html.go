@@ -1,40 +1,153 @@
 package crawler
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/transform"
 )
 
-func (cfg *Config) getHTML(rawURL string) (string, error) {
-	req, err := http.NewRequest("GET", rawURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("got Network error: %v", err)
-	}
-	req.Header.Set("User-Agent", cfg.UserAgent)
+type htmlResponse struct {
+	Doc         *html.Node
+	Truncated   bool
+	StatusCode  int
+	FinalURL    string
+	Redirects   []RedirectHop
+	Header      http.Header
+	Charset     pageCharset
+	Proxy       string
+	FromCache   bool
+	ContentType string
+	// ContentEncoding, TransferSize and DecodedSize describe the body as
+	// sent over the wire and after decompression.
+	ContentEncoding string
+	TransferSize    int64
+	DecodedSize     int64
+}
+
+func isHTMLContentType(contentType string) bool {
+	return strings.Contains(contentType, "text/html")
+}
+
+// isHTMLPage reports whether a crawled response is one the page-level audits
+// look at: a 200 HTML response that wasn't redirected.
+func isHTMLPage(statusCode int, finalURL, contentType string) bool {
+	return statusCode == http.StatusOK && finalURL == "" && isHTMLContentType(contentType)
+}
 
-	res, err := http.DefaultClient.Do(req)
+// getHTML returns the response alongside any HTTP or content-type error so
+// callers can still record the status and redirects of pages that couldn't
+// be parsed.
+func (cfg *Config) getHTML(rawURL string) (*htmlResponse, error) {
+	res, redirects, err := cfg.followRedirects(rawURL)
 	if err != nil {
-		return "", fmt.Errorf("got Network error: %v", err)
+		if len(redirects) == 0 {
+			return nil, err
+		}
+		lastHop := redirects[len(redirects)-1]
+		return &htmlResponse{
+			StatusCode: lastHop.StatusCode,
+			FinalURL:   lastHop.Location,
+			Redirects:  redirects,
+			Header:     http.Header{},
+		}, err
 	}
 	defer res.Body.Close()
 
+	htmlRes := &htmlResponse{
+		StatusCode:  res.StatusCode,
+		Redirects:   redirects,
+		Header:      res.Header,
+		Proxy:       proxyUsed(res),
+		FromCache:   servedFromCache(res),
+		ContentType: res.Header.Get("Content-Type"),
+	}
+	if len(redirects) > 0 {
+		htmlRes.FinalURL = res.Request.URL.String()
+	}
+
 	if res.StatusCode > 399 {
-		return "", fmt.Errorf("got HTTP error: %s", res.Status)
+		return htmlRes, fmt.Errorf("got HTTP error: %s", res.Status)
+	}
+
+	contentType := htmlRes.ContentType
+	if !isHTMLContentType(contentType) {
+		return htmlRes, fmt.Errorf("got non-HTML response: %s", contentType)
+	}
+
+	htmlRes.ContentEncoding = res.Header.Get("Content-Encoding")
+	transferred := &countingReader{r: res.Body}
+	body, err := decodeContentEncoding(htmlRes.ContentEncoding, transferred)
+	if err != nil {
+		return htmlRes, err
 	}
+	defer body.Close()
 
-	contentType := res.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "text/html") {
-		return "", fmt.Errorf("got non-HTML response: %s", contentType)
+	// the limit applies to the decoded size so a small compressed body can't
+	// expand without bound; one byte past it tells an oversized body apart
+	// from one that is exactly the limit
+	htmlBodyBytes, err := io.ReadAll(io.LimitReader(body, cfg.MaxBodySize+1))
+	// a page revalidated from the cache came back as a 304 without a body
+	if !htmlRes.FromCache {
+		htmlRes.TransferSize = transferred.n
+	}
+	if err != nil {
+		return htmlRes, fmt.Errorf("couldn't read response body: %v", err)
 	}
+	if int64(len(htmlBodyBytes)) > cfg.MaxBodySize {
+		htmlBodyBytes = trimPartialRune(htmlBodyBytes[:cfg.MaxBodySize])
+		htmlRes.Truncated = true
+	}
+	htmlRes.DecodedSize = int64(len(htmlBodyBytes))
+
+	enc, pageCharset := detectCharset(htmlBodyBytes, contentType)
+	htmlRes.Charset = pageCharset
 
-	htmlBodyBytes, err := io.ReadAll(res.Body)
+	// decode while parsing so the transcoded page is never held in memory
+	// alongside the raw bytes
+	doc, err := html.Parse(transform.NewReader(bytes.NewReader(htmlBodyBytes), enc.NewDecoder()))
 	if err != nil {
-		return "", fmt.Errorf("couldn't read response body: %v", err)
+		return htmlRes, fmt.Errorf("couldn't parse HTML: %v", err)
+	}
+	htmlRes.Doc = doc
+
+	return htmlRes, nil
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off by truncation, so a
+// truncated UTF-8 page isn't reported as invalid.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			return b
+		}
 	}
+	return b
+}
 
-	htmlBody := string(htmlBodyBytes)
+// writeTruncatedPagesText lists pages cut off at the body size limit; nothing
+// is written when every page fit.
+func writeTruncatedPagesText(w io.Writer, pages []Page) {
+	var truncated []Page
+	for _, page := range pages {
+		if page.Truncated {
+			truncated = append(truncated, page)
+		}
+	}
+	if len(truncated) == 0 {
+		return
+	}
 
-	return htmlBody, nil
+	writeSectionHeader(w, "TRUNCATED PAGES")
+	for _, page := range truncated {
+		fmt.Fprintf(w, "  - %s\n", page.URL)
+	}
 }
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGetHTMLBodyLimit(t *testing.T) {
+	page := `<html><head><title>Limits</title></head><body><a href="/first">first</a>` +
+		strings.Repeat("<p>padding</p>", 100) +
+		`<a href="/last">last</a></body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name              string
+		maxBodySize       int64
+		expectedTruncated bool
+		expectedLinks     int
+	}{
+		{
+			name:          "page within limit",
+			maxBodySize:   int64(len(page)),
+			expectedLinks: 2,
+		},
+		{
+			name:              "page over limit is parsed up to it",
+			maxBodySize:       200,
+			expectedTruncated: true,
+			expectedLinks:     1,
+		},
+	}
+
+	pageURL, _ := url.Parse(server.URL)
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{UserAgent: "Crawler", HTTPClient: NewHTTPClient(DefaultClientOptions()), MaxBodySize: tc.maxBodySize}
+			htmlRes, err := cfg.getHTML(server.URL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+
+			if htmlRes.Truncated != tc.expectedTruncated {
+				t.Errorf("Test %v - %s FAIL: expected truncated %v, actual: %v", i, tc.name, tc.expectedTruncated, htmlRes.Truncated)
+			}
+			links := extractLinks(htmlRes.Doc, pageURL)
+			if len(links) != tc.expectedLinks {
+				t.Errorf("Test %v - %s FAIL: expected %d links, actual: %v", i, tc.name, tc.expectedLinks, links)
+			}
+		})
+	}
+}
@@ -8,28 +8,93 @@ import (
 	"golang.org/x/net/html"
 )
 
-func getURLsFromHTML(htmlBody string, baseURL *url.URL) ([]string, error) {
-	htmlReader := strings.NewReader(htmlBody)
-	doc, err := html.Parse(htmlReader)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't parse HTML: %v", err)
+type LinkKind string
+
+const (
+	LinkAnchor     LinkKind = "anchor"
+	LinkArea       LinkKind = "area"
+	LinkIFrame     LinkKind = "iframe"
+	LinkForm       LinkKind = "form"
+	LinkRel        LinkKind = "link"
+	LinkImage      LinkKind = "image"
+	LinkScript     LinkKind = "script"
+	LinkStylesheet LinkKind = "stylesheet"
+	LinkFont       LinkKind = "font"
+)
+
+// IsAsset reports whether links of this kind point at page resources that
+// should be checked rather than crawled.
+func (kind LinkKind) IsAsset() bool {
+	return kind == LinkImage || kind == LinkScript || kind == LinkStylesheet || kind == LinkFont
+}
+
+type Link struct {
+	URL  string   `json:"url"`
+	Kind LinkKind `json:"kind"`
+}
+
+// extractLinks returns the page and asset links in an already parsed document.
+func extractLinks(doc *html.Node, pageURL *url.URL) []Link {
+	baseURL := documentBaseURL(doc, pageURL)
+
+	var links []Link
+	addLink := func(rawURL string, kind LinkKind) {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			return
+		}
+		href, err := url.Parse(rawURL)
+		if err != nil {
+			fmt.Printf("couldn't parse href '%v': %v\n", rawURL, err)
+			return
+		}
+
+		resolvedURL := baseURL.ResolveReference(href)
+		if resolvedURL.Scheme != "http" && resolvedURL.Scheme != "https" {
+			return
+		}
+		links = append(links, Link{URL: resolvedURL.String(), Kind: kind})
 	}
 
-	var urls []string
 	var traverseNodes func(*html.Node)
 	traverseNodes = func(node *html.Node) {
-		if node.Type == html.ElementNode && node.Data == "a" {
-			for _, anchor := range node.Attr {
-				if anchor.Key == "href" {
-					href, err := url.Parse(anchor.Val)
-					if err != nil {
-						fmt.Printf("couldn't parse href '%v': %v\n", anchor.Val, err)
-						continue
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "a":
+				addLink(getAttr(node, "href"), LinkAnchor)
+			case "area":
+				addLink(getAttr(node, "href"), LinkArea)
+			case "iframe":
+				addLink(getAttr(node, "src"), LinkIFrame)
+			case "form":
+				method := strings.ToLower(getAttr(node, "method"))
+				if method == "" || method == "get" {
+					addLink(getAttr(node, "action"), LinkForm)
+				}
+			case "link":
+				for _, rel := range strings.Fields(strings.ToLower(getAttr(node, "rel"))) {
+					switch rel {
+					case "next", "prev", "previous", "alternate":
+						addLink(getAttr(node, "href"), LinkRel)
+					case "stylesheet":
+						addLink(getAttr(node, "href"), LinkStylesheet)
+					case "preload":
+						if strings.EqualFold(getAttr(node, "as"), "font") {
+							addLink(getAttr(node, "href"), LinkFont)
+						}
 					}
-
-					resolvedURL := baseURL.ResolveReference(href)
-					urls = append(urls, resolvedURL.String())
 				}
+			case "img":
+				addLink(getAttr(node, "src"), LinkImage)
+				for _, candidate := range parseSrcset(getAttr(node, "srcset")) {
+					addLink(candidate, LinkImage)
+				}
+			case "source":
+				for _, candidate := range parseSrcset(getAttr(node, "srcset")) {
+					addLink(candidate, LinkImage)
+				}
+			case "script":
+				addLink(getAttr(node, "src"), LinkScript)
 			}
 		}
 
@@ -39,5 +104,77 @@ func getURLsFromHTML(htmlBody string, baseURL *url.URL) ([]string, error) {
 	}
 	traverseNodes(doc)
 
-	return urls, nil
+	return links
+}
+
+// documentBaseURL returns the first <base href> resolved against pageURL.
+func documentBaseURL(doc *html.Node, pageURL *url.URL) *url.URL {
+	var base *url.URL
+	var find func(*html.Node)
+	find = func(node *html.Node) {
+		if base != nil {
+			return
+		}
+		if node.Type == html.ElementNode && node.Data == "base" {
+			if href := strings.TrimSpace(getAttr(node, "href")); href != "" {
+				if parsed, err := url.Parse(href); err == nil {
+					base = pageURL.ResolveReference(parsed)
+					return
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			find(child)
+		}
+	}
+	find(doc)
+
+	if base == nil {
+		return pageURL
+	}
+	return base
+}
+
+// parseSrcset returns the URLs of a srcset attribute's image candidates.
+// URLs may themselves contain commas, so candidates are split the way the
+// HTML spec does rather than on every comma.
+func parseSrcset(srcset string) []string {
+	var urls []string
+	rest := srcset
+	for {
+		rest = strings.TrimLeft(rest, " \t\n\r\f,")
+		if rest == "" {
+			return urls
+		}
+
+		end := strings.IndexAny(rest, " \t\n\r\f")
+		if end == -1 {
+			end = len(rest)
+		}
+		candidate := rest[:end]
+		rest = rest[end:]
+
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			// a trailing comma ends a candidate without descriptors
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, candidate)
+
+		// skip the descriptors, e.g. "2x" or "480w"
+		if idx := strings.Index(rest, ","); idx != -1 {
+			rest = rest[idx+1:]
+		} else {
+			rest = ""
+		}
+	}
+}
+
+func getAttr(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
 }
@@ -5,15 +5,16 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"golang.org/x/net/html"
 )
 
-func TestGetURLsFromHTML(t *testing.T) {
+func TestExtractLinksPages(t *testing.T) {
 	cases := []struct {
-		name          string
-		inputURL      string
-		inputBody     string
-		expected      []string
-		errorContains string
+		name      string
+		inputURL  string
+		inputBody string
+		expected  []string
 	}{
 		{
 			name:     "absolute URL",
@@ -110,16 +111,16 @@ func TestGetURLsFromHTML(t *testing.T) {
 				return
 			}
 
-			actual, err := getURLsFromHTML(tc.inputBody, baseURL)
-			if err != nil && !strings.Contains(err.Error(), tc.errorContains) {
-				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
-				return
-			} else if err != nil && tc.errorContains == "" {
+			doc, err := html.Parse(strings.NewReader(tc.inputBody))
+			if err != nil {
 				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
 				return
-			} else if err == nil && tc.errorContains != "" {
-				t.Errorf("Test %v - '%s' FAIL: expected error containing '%v', got none.", i, tc.name, tc.errorContains)
-				return
+			}
+			var actual []string
+			for _, link := range extractLinks(doc, baseURL) {
+				if !link.Kind.IsAsset() {
+					actual = append(actual, link.URL)
+				}
 			}
 
 			if !reflect.DeepEqual(actual, tc.expected) {
@@ -129,3 +130,96 @@ func TestGetURLsFromHTML(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractLinks(t *testing.T) {
+	cases := []struct {
+		name      string
+		inputURL  string
+		inputBody string
+		expected  []Link
+	}{
+		{
+			name:      "relative to nested page",
+			inputURL:  "https://blog.boot.dev/posts/go/",
+			inputBody: `<html><body><a href="intro">Intro</a><a href="../rust/">Rust</a></body></html>`,
+			expected: []Link{
+				{URL: "https://blog.boot.dev/posts/go/intro", Kind: LinkAnchor},
+				{URL: "https://blog.boot.dev/posts/rust/", Kind: LinkAnchor},
+			},
+		},
+		{
+			name:     "base href",
+			inputURL: "https://blog.boot.dev/posts/go/",
+			inputBody: `<html><head><base href="/docs/"></head>
+<body><a href="intro">Intro</a></body></html>`,
+			expected: []Link{
+				{URL: "https://blog.boot.dev/docs/intro", Kind: LinkAnchor},
+			},
+		},
+		{
+			name:     "page link kinds",
+			inputURL: "https://blog.boot.dev/page/2",
+			inputBody: `<html><head>
+<link rel="next" href="/page/3">
+<link rel="prev" href="/page/1">
+<link rel="alternate" hreflang="de" href="/de/page/2">
+<link rel="icon" href="/favicon.ico">
+</head><body>
+<map><area href="/map-target"></map>
+<iframe src="/embed"></iframe>
+<form action="/search"></form>
+<form method="post" action="/login"></form>
+<a href="mailto:hi@boot.dev">mail</a>
+<a href="javascript:void(0)">js</a>
+</body></html>`,
+			expected: []Link{
+				{URL: "https://blog.boot.dev/page/3", Kind: LinkRel},
+				{URL: "https://blog.boot.dev/page/1", Kind: LinkRel},
+				{URL: "https://blog.boot.dev/de/page/2", Kind: LinkRel},
+				{URL: "https://blog.boot.dev/map-target", Kind: LinkArea},
+				{URL: "https://blog.boot.dev/embed", Kind: LinkIFrame},
+				{URL: "https://blog.boot.dev/search", Kind: LinkForm},
+			},
+		},
+		{
+			name:     "asset kinds",
+			inputURL: "https://blog.boot.dev/",
+			inputBody: `<html><head>
+<link rel="stylesheet" href="/style.css">
+<script src="/app.js"></script>
+</head><body>
+<img src="/a.png" srcset="/a-2x.png 2x, https://cdn.boot.dev/w_100,h_100/a.png 100w">
+<picture><source srcset="/b.webp"></picture>
+</body></html>`,
+			expected: []Link{
+				{URL: "https://blog.boot.dev/style.css", Kind: LinkStylesheet},
+				{URL: "https://blog.boot.dev/app.js", Kind: LinkScript},
+				{URL: "https://blog.boot.dev/a.png", Kind: LinkImage},
+				{URL: "https://blog.boot.dev/a-2x.png", Kind: LinkImage},
+				{URL: "https://cdn.boot.dev/w_100,h_100/a.png", Kind: LinkImage},
+				{URL: "https://blog.boot.dev/b.webp", Kind: LinkImage},
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pageURL, err := url.Parse(tc.inputURL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: couldn't parse input URL: %v", i, tc.name, err)
+				return
+			}
+
+			doc, err := html.Parse(strings.NewReader(tc.inputBody))
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			actual := extractLinks(doc, pageURL)
+
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - '%s' FAIL: expected links %v, got links %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
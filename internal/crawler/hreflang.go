@@ -0,0 +1,357 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/language"
+)
+
+// HreflangLink is one alternate-language annotation. URL is kept as written
+// and resolved against the page when audited.
+type HreflangLink struct {
+	Lang   string `json:"lang"`
+	URL    string `json:"url"`
+	Source string `json:"source"` // "html" or "header"
+}
+
+// extractHreflang reads <link rel="alternate" hreflang> annotations.
+func extractHreflang(doc *html.Node, meta *PageMetadata) {
+	walkElements(doc, func(n *html.Node) {
+		if n.Data != "link" || !hasAttr(n, "hreflang") || !hasRelToken(getAttr(n, "rel"), "alternate") {
+			return
+		}
+		meta.Hreflang = append(meta.Hreflang, HreflangLink{
+			Lang:   strings.TrimSpace(getAttr(n, "hreflang")),
+			URL:    strings.TrimSpace(getAttr(n, "href")),
+			Source: "html",
+		})
+	})
+}
+
+func hasRelToken(rel, token string) bool {
+	for _, t := range strings.Fields(rel) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// hreflangFromHeader reads annotations sent in HTTP Link headers, e.g.
+// Link: <https://example.com/de/>; rel="alternate"; hreflang="de"
+func hreflangFromHeader(header http.Header) []HreflangLink {
+	var links []HreflangLink
+	for _, value := range header.Values("Link") {
+		for _, link := range parseLinkHeader(value) {
+			lang, ok := link.params["hreflang"]
+			if !ok || !hasRelToken(link.params["rel"], "alternate") {
+				continue
+			}
+			links = append(links, HreflangLink{Lang: lang, URL: link.url, Source: "header"})
+		}
+	}
+	return links
+}
+
+type headerLink struct {
+	url    string
+	params map[string]string
+}
+
+// parseLinkHeader splits an RFC 8288 Link header into its links. Commas
+// inside the <URL> or a quoted parameter don't end a link.
+func parseLinkHeader(value string) []headerLink {
+	var links []headerLink
+	for {
+		start := strings.Index(value, "<")
+		if start == -1 {
+			return links
+		}
+		end := strings.Index(value[start:], ">")
+		if end == -1 {
+			return links
+		}
+		link := headerLink{url: strings.TrimSpace(value[start+1 : start+end]), params: map[string]string{}}
+		value = value[start+end+1:]
+
+		// parameters run up to the next comma outside quotes
+		inQuotes := false
+		stop := len(value)
+		for i, r := range value {
+			if r == '"' {
+				inQuotes = !inQuotes
+			} else if r == ',' && !inQuotes {
+				stop = i
+				break
+			}
+		}
+		for _, param := range strings.Split(value[:stop], ";") {
+			name, paramValue, _ := strings.Cut(param, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			link.params[name] = strings.Trim(strings.TrimSpace(paramValue), `"`)
+		}
+		links = append(links, link)
+		value = value[stop:]
+	}
+}
+
+var hreflangPattern = regexp.MustCompile(`^([A-Za-z]{2,3})(?:-([A-Za-z]{4}))?(?:-([A-Za-z]{2}))?$`)
+
+// hreflangCodeProblem explains why code isn't an ISO 639-1 language,
+// optionally followed by an ISO 15924 script and an ISO 3166-1 alpha-2
+// region; it returns "" for valid codes and x-default.
+func hreflangCodeProblem(code string) string {
+	if strings.EqualFold(code, "x-default") {
+		return ""
+	}
+	parts := hreflangPattern.FindStringSubmatch(code)
+	if parts == nil {
+		return "not a language or language-region code"
+	}
+	base, err := language.ParseBase(parts[1])
+	if err != nil {
+		return fmt.Sprintf("unknown language %q", parts[1])
+	}
+	if len(parts[1]) == 3 && len(base.String()) == 2 {
+		return fmt.Sprintf("use the two-letter language code %q", base.String())
+	}
+	if parts[2] != "" {
+		if _, err := language.ParseScript(parts[2]); err != nil {
+			return fmt.Sprintf("unknown script %q", parts[2])
+		}
+	}
+	if parts[3] != "" {
+		if strings.EqualFold(parts[3], "UK") {
+			return `unknown region "UK", use "GB"`
+		}
+		region, err := language.ParseRegion(parts[3])
+		if err != nil || !region.IsCountry() {
+			return fmt.Sprintf("unknown region %q", parts[3])
+		}
+	}
+	return ""
+}
+
+// primaryLanguage returns the language subtag of a code such as en-GB.
+func primaryLanguage(code string) string {
+	primary, _, _ := strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+	return strings.ToLower(primary)
+}
+
+type HreflangIssue struct {
+	URL     string `json:"url"`
+	Lang    string `json:"hreflang,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// HreflangAudit checks the alternate-language annotations across the crawl.
+type HreflangAudit struct {
+	PagesWithHreflang    int             `json:"pages_with_hreflang"`
+	InvalidCodes         []HreflangIssue `json:"invalid_codes"`
+	Conflicts            []HreflangIssue `json:"conflicts"`
+	MissingXDefault      []string        `json:"missing_x_default"`
+	MissingSelfReference []string        `json:"missing_self_reference"`
+	MissingReturnLinks   []HreflangIssue `json:"missing_return_links"`
+	BadTargets           []HreflangIssue `json:"bad_targets"`
+	LangMismatches       []HreflangIssue `json:"lang_mismatches"`
+}
+
+// AuditHreflang must be called after the crawl has finished. In-scope
+// targets that weren't crawled are requested once to check their status;
+// other uncrawled targets are skipped.
+func (cfg *Config) AuditHreflang() *HreflangAudit {
+	audit := &HreflangAudit{
+		InvalidCodes:         []HreflangIssue{},
+		Conflicts:            []HreflangIssue{},
+		MissingXDefault:      []string{},
+		MissingSelfReference: []string{},
+		MissingReturnLinks:   []HreflangIssue{},
+		BadTargets:           []HreflangIssue{},
+		LangMismatches:       []HreflangIssue{},
+	}
+	unchecked := map[string][]HreflangIssue{} // target URL -> annotations pointing at it
+
+	cfg.Mu.Lock()
+
+	for pageKey, data := range cfg.Pages {
+		if data.StatusCode != http.StatusOK || data.FinalURL != "" || len(data.Hreflang) == 0 {
+			continue
+		}
+		audit.PagesWithHreflang++
+
+		targets := map[string]string{} // lowercased hreflang -> target key
+		hasXDefault, hasSelf := false, false
+		for i, link := range data.Hreflang {
+			lang := strings.ToLower(link.Lang)
+			targetKey, resolved, ok := cfg.hreflangTarget(data, i)
+			issue := HreflangIssue{URL: data.URL, Lang: link.Lang, Target: resolved}
+			if !ok {
+				issue.Target = link.URL
+			}
+			if lang == "x-default" {
+				hasXDefault = true
+			} else if problem := hreflangCodeProblem(link.Lang); problem != "" {
+				issue.Message = problem
+				audit.InvalidCodes = append(audit.InvalidCodes, issue)
+			}
+			if !ok {
+				issue.Message = "invalid URL"
+				audit.BadTargets = append(audit.BadTargets, issue)
+				continue
+			}
+			if previous, seen := targets[lang]; seen && previous != targetKey {
+				issue.Message = "hreflang listed more than once with different URLs"
+				audit.Conflicts = append(audit.Conflicts, issue)
+			}
+			targets[lang] = targetKey
+			if targetKey == pageKey {
+				hasSelf = true
+			}
+
+			target, crawled := cfg.Pages[targetKey]
+			if !crawled || target.StatusCode == 0 {
+				if cfg.inScope(resolved) {
+					unchecked[resolved] = append(unchecked[resolved], issue)
+				}
+				continue
+			}
+			if message := hreflangTargetProblem(cfg.Normalizer, resolved, targetKey, target); message != "" {
+				issue.Message = message
+				audit.BadTargets = append(audit.BadTargets, issue)
+				continue
+			}
+			if targetKey != pageKey && !cfg.hreflangLinksTo(target, pageKey) {
+				issue.Message = "target doesn't link back"
+				audit.MissingReturnLinks = append(audit.MissingReturnLinks, issue)
+			}
+			if lang != "x-default" && target.Language != "" && primaryLanguage(target.Language) != primaryLanguage(lang) {
+				issue.Message = fmt.Sprintf("target has <html lang=%q>", target.Language)
+				audit.LangMismatches = append(audit.LangMismatches, issue)
+			}
+		}
+		if !hasXDefault {
+			audit.MissingXDefault = append(audit.MissingXDefault, data.URL)
+		}
+		if !hasSelf {
+			audit.MissingSelfReference = append(audit.MissingSelfReference, data.URL)
+		}
+	}
+
+	cfg.Mu.Unlock()
+
+	rawURLs := make([]string, 0, len(unchecked))
+	for rawURL := range unchecked {
+		rawURLs = append(rawURLs, rawURL)
+	}
+	for _, result := range cfg.checkURLStatuses(rawURLs) {
+		message := fmt.Sprintf("target returns %d", result.StatusCode)
+		if result.Location != "" {
+			message = "target redirects to " + result.Location
+		}
+		for _, issue := range unchecked[result.URL] {
+			issue.Message = message
+			audit.BadTargets = append(audit.BadTargets, issue)
+		}
+	}
+
+	for _, issues := range [][]HreflangIssue{audit.InvalidCodes, audit.Conflicts, audit.MissingReturnLinks, audit.BadTargets, audit.LangMismatches} {
+		sortHreflangIssues(issues)
+	}
+	sort.Strings(audit.MissingXDefault)
+	sort.Strings(audit.MissingSelfReference)
+	return audit
+}
+
+// hreflangTarget resolves the i-th annotation of a page and normalizes it.
+func (cfg *Config) hreflangTarget(data *PageData, i int) (key, resolved string, ok bool) {
+	pageURL, err := url.Parse(data.URL)
+	if err != nil {
+		return "", "", false
+	}
+	targetURL, err := url.Parse(data.Hreflang[i].URL)
+	if err != nil || data.Hreflang[i].URL == "" {
+		return "", "", false
+	}
+	resolved = pageURL.ResolveReference(targetURL).String()
+	key, err = cfg.Normalizer.Normalize(resolved)
+	if err != nil {
+		return "", "", false
+	}
+	return key, resolved, true
+}
+
+func (cfg *Config) hreflangLinksTo(data *PageData, pageKey string) bool {
+	for i := range data.Hreflang {
+		if key, _, ok := cfg.hreflangTarget(data, i); ok && key == pageKey {
+			return true
+		}
+	}
+	return false
+}
+
+// hreflangTargetProblem reports why a crawled page can't be an alternate:
+// search engines ignore annotations pointing at redirects, errors, noindexed
+// pages and pages canonicalised elsewhere.
+func hreflangTargetProblem(normalizer URLNormalizer, resolved, targetKey string, target *PageData) string {
+	hops := redirectHopsFrom(resolved, target)
+	switch {
+	case len(hops) > 0:
+		return "target redirects to " + hops[len(hops)-1].Location
+	case target.StatusCode != http.StatusOK:
+		return fmt.Sprintf("target returns %d", target.StatusCode)
+	case isNoindex(target):
+		return "target is noindexed"
+	}
+	if canonical, elsewhere := canonicalElsewhere(normalizer, targetKey, target); elsewhere {
+		return "target is canonicalised to " + canonical
+	}
+	return ""
+}
+
+func sortHreflangIssues(issues []HreflangIssue) {
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].URL != issues[j].URL {
+			return issues[i].URL < issues[j].URL
+		}
+		return issues[i].Lang < issues[j].Lang
+	})
+}
+
+func (audit *HreflangAudit) writeText(w io.Writer) {
+	writeSectionHeader(w, "HREFLANG")
+	fmt.Fprintf(w, "%d pages with hreflang annotations\n", audit.PagesWithHreflang)
+
+	writeIssues := func(title string, issues []HreflangIssue) {
+		fmt.Fprintf(w, "\n%s: %d\n", title, len(issues))
+		for _, issue := range issues {
+			fmt.Fprintf(w, "  - %s [%s] -> %s: %s\n", issue.URL, issue.Lang, issue.Target, issue.Message)
+		}
+	}
+	writeIssues("Invalid hreflang codes", audit.InvalidCodes)
+	writeIssues("Conflicting hreflang annotations", audit.Conflicts)
+
+	fmt.Fprintf(w, "\nPages without x-default: %d\n", len(audit.MissingXDefault))
+	for _, u := range audit.MissingXDefault {
+		fmt.Fprintf(w, "  - %s\n", u)
+	}
+
+	fmt.Fprintf(w, "\nPages that don't list themselves: %d\n", len(audit.MissingSelfReference))
+	for _, u := range audit.MissingSelfReference {
+		fmt.Fprintf(w, "  - %s\n", u)
+	}
+
+	writeIssues("Missing return links", audit.MissingReturnLinks)
+	writeIssues("Targets that redirect, fail, are noindexed or canonicalised elsewhere", audit.BadTargets)
+	writeIssues("hreflang and <html lang> mismatches", audit.LangMismatches)
+}
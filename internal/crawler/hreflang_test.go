@@ -0,0 +1,174 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractHreflang(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head>
+		<link rel="alternate" hreflang="en" href="https://blog.boot.dev/en/">
+		<link rel="Alternate nofollow" hreflang=" de-DE " href="/de/">
+		<link rel="alternate" type="application/rss+xml" href="/feed.xml">
+		<link rel="canonical" hreflang="fr" href="/fr/">
+	</head></html>`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var meta PageMetadata
+	extractHreflang(doc, &meta)
+
+	expected := []HreflangLink{
+		{Lang: "en", URL: "https://blog.boot.dev/en/", Source: "html"},
+		{Lang: "de-DE", URL: "/de/", Source: "html"},
+	}
+	if !reflect.DeepEqual(meta.Hreflang, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, meta.Hreflang)
+	}
+}
+
+func TestHreflangFromHeader(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://blog.boot.dev/de/>; rel="alternate"; hreflang="de", <https://blog.boot.dev/a,b>; rel=alternate; hreflang=x-default`)
+	header.Add("Link", `<https://blog.boot.dev/style.css>; rel="preload"; as="style", <https://blog.boot.dev/>; rel="canonical"`)
+
+	expected := []HreflangLink{
+		{Lang: "de", URL: "https://blog.boot.dev/de/", Source: "header"},
+		{Lang: "x-default", URL: "https://blog.boot.dev/a,b", Source: "header"},
+	}
+	if actual := hreflangFromHeader(header); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, actual)
+	}
+}
+
+func TestHreflangCodeProblem(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "en", valid: true},
+		{code: "en-GB", valid: true},
+		{code: "zh-Hant-TW", valid: true},
+		{code: "X-Default", valid: true},
+		{code: "en-UK", valid: false},
+		{code: "en_US", valid: false},
+		{code: "eng", valid: false},
+		{code: "xx", valid: false},
+		{code: "es-419", valid: false},
+		{code: "en-EU", valid: false},
+		{code: "", valid: false},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.code, func(t *testing.T) {
+			problem := hreflangCodeProblem(tc.code)
+			if (problem == "") != tc.valid {
+				t.Errorf("Test %v - %s FAIL: expected valid: %v, actual problem: %q", i, tc.code, tc.valid, problem)
+			}
+		})
+	}
+}
+
+func TestAuditHreflang(t *testing.T) {
+	page := func(rawURL, lang string, links ...HreflangLink) *PageData {
+		return &PageData{URL: rawURL, StatusCode: 200, PageMetadata: PageMetadata{Language: lang, Hreflang: links}}
+	}
+	link := func(lang, rawURL string) HreflangLink {
+		return HreflangLink{Lang: lang, URL: rawURL, Source: "html"}
+	}
+
+	cfg := &Config{
+		Normalizer: DefaultURLNormalizer(),
+		Mu:         &sync.Mutex{},
+		Pages: map[string]*PageData{
+			"blog.boot.dev/en": page("https://blog.boot.dev/en", "en",
+				link("en", "/en"), link("de", "/de"), link("fr", "/fr"), link("es", "/es"), link("x-default", "/en")),
+			"blog.boot.dev/de": page("https://blog.boot.dev/de", "de",
+				link("en", "/en"), link("x-default", "/en")),
+			// no return link, wrong lang, no x-default
+			"blog.boot.dev/fr": page("https://blog.boot.dev/fr", "en-US",
+				link("fr", "/fr")),
+			"blog.boot.dev/es": {URL: "https://blog.boot.dev/es", StatusCode: 200, PageMetadata: PageMetadata{Robots: "noindex"}},
+			"blog.boot.dev/it": page("https://blog.boot.dev/it", "it",
+				link("it_IT", "/it"), link("en", "/en"), link("en", "/en-gb"), link("x-default", "/en")),
+		},
+	}
+
+	audit := cfg.AuditHreflang()
+	if audit.PagesWithHreflang != 4 {
+		t.Errorf("expected 4 pages with hreflang, actual: %d", audit.PagesWithHreflang)
+	}
+
+	type check struct {
+		name     string
+		issues   []HreflangIssue
+		expected []string // "URL [lang]"
+	}
+	for _, c := range []check{
+		{"invalid codes", audit.InvalidCodes, []string{"https://blog.boot.dev/it [it_IT]"}},
+		{"conflicts", audit.Conflicts, []string{"https://blog.boot.dev/it [en]"}},
+		{"missing return links", audit.MissingReturnLinks, []string{"https://blog.boot.dev/en [fr]", "https://blog.boot.dev/it [en]", "https://blog.boot.dev/it [x-default]"}},
+		{"bad targets", audit.BadTargets, []string{"https://blog.boot.dev/en [es]"}},
+		{"lang mismatches", audit.LangMismatches, []string{"https://blog.boot.dev/en [fr]", "https://blog.boot.dev/fr [fr]"}},
+	} {
+		var actual []string
+		for _, issue := range c.issues {
+			actual = append(actual, issue.URL+" ["+issue.Lang+"]")
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected: %v, actual: %+v", c.name, c.expected, c.issues)
+		}
+	}
+
+	if expected := []string{"https://blog.boot.dev/fr"}; !reflect.DeepEqual(audit.MissingXDefault, expected) {
+		t.Errorf("expected missing x-default %v, actual: %v", expected, audit.MissingXDefault)
+	}
+	if expected := []string{"https://blog.boot.dev/de"}; !reflect.DeepEqual(audit.MissingSelfReference, expected) {
+		t.Errorf("expected missing self reference %v, actual: %v", expected, audit.MissingSelfReference)
+	}
+}
+
+func TestAuditHreflangUncrawledTargets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	links := []HreflangLink{}
+	for lang, path := range map[string]string{"en": "/en", "de": "/gone", "fr": "/moved", "es": "/ok", "it": "https://example.com/it"} {
+		links = append(links, HreflangLink{Lang: lang, URL: path, Source: "html"})
+	}
+	pageKey, _ := DefaultURLNormalizer().Normalize(server.URL + "/en")
+	cfg := &Config{
+		Normalizer:         DefaultURLNormalizer(),
+		Scope:              Scope{AllowedHosts: []string{serverURL.Hostname()}},
+		HTTPClient:         NewHTTPClient(DefaultClientOptions()),
+		Mu:                 &sync.Mutex{},
+		ConcurrencyControl: make(chan struct{}, 2),
+		Pages: map[string]*PageData{
+			pageKey: {URL: server.URL + "/en", StatusCode: 200, PageMetadata: PageMetadata{Language: "en", Hreflang: links}},
+		},
+	}
+
+	expected := []HreflangIssue{
+		{URL: server.URL + "/en", Lang: "de", Target: server.URL + "/gone", Message: "target returns 404"},
+		{URL: server.URL + "/en", Lang: "fr", Target: server.URL + "/moved", Message: "target redirects to " + server.URL + "/ok"},
+	}
+	if actual := cfg.AuditHreflang().BadTargets; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, actual)
+	}
+}
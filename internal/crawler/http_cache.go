@@ -0,0 +1,232 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheEntry is the metadata stored next to each cached body.
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Size       int64       `json:"size"`
+	StoredAt   time.Time   `json:"stored_at"`
+}
+
+type cacheContextKey struct{}
+
+// cacheTransport keeps an on-disk copy of every GET response that has an
+// ETag or Last-Modified and revalidates it with a conditional request on the
+// next crawl. A 304 is turned back into the cached 200 so callers don't need
+// to know the cache exists. Responses larger than maxEntrySize aren't stored.
+type cacheTransport struct {
+	dir          string
+	maxEntrySize int64
+	base         http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	entry, hasEntry := t.load(key)
+	original := req
+	if hasEntry {
+		req = req.Clone(req.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotModified && hasEntry {
+		res.Body.Close()
+		cached, err := t.cachedResponse(key, entry, res)
+		if err == nil {
+			return cached, nil
+		}
+		// the stored body is gone or damaged, so drop the entry and fetch
+		// the page in full
+		fmt.Printf("Error - cache: %v\n", err)
+		t.evict(key)
+		return t.RoundTrip(original)
+	}
+	if !isCacheable(res) {
+		return res, nil
+	}
+
+	// buffer the body so it can be stored, unless it's too big to cache
+	body, err := io.ReadAll(io.LimitReader(res.Body, t.maxEntrySize+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.maxEntrySize {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry = cacheEntry{
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Size:       int64(len(body)),
+		StoredAt:   time.Now(),
+	}
+	if err := t.store(key, entry, body); err != nil {
+		fmt.Printf("Error - cache: %v\n", err)
+	}
+	return res, nil
+}
+
+// cachedResponse rebuilds the stored response, refreshed with the validators
+// and freshness headers the 304 carried.
+func (t *cacheTransport) cachedResponse(key string, entry cacheEntry, notModified *http.Response) (*http.Response, error) {
+	body, err := os.Open(filepath.Join(t.dir, key+".body"))
+	if err != nil {
+		return nil, err
+	}
+	info, err := body.Stat()
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	if info.Size() != entry.Size {
+		body.Close()
+		return nil, fmt.Errorf("cached body for %s is %d bytes, expected %d", entry.URL, info.Size(), entry.Size)
+	}
+
+	header := entry.Header.Clone()
+	for _, name := range []string{"Cache-Control", "Content-Location", "Date", "ETag", "Expires", "Last-Modified", "Vary"} {
+		if value := notModified.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	entry.Header = header
+	if err := t.storeEntry(key, entry); err != nil {
+		fmt.Printf("Error - cache: %v\n", err)
+	}
+
+	ctx := context.WithValue(notModified.Request.Context(), cacheContextKey{}, true)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+		StatusCode:    entry.StatusCode,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          body,
+		ContentLength: info.Size(),
+		Request:       notModified.Request.WithContext(ctx),
+	}, nil
+}
+
+func isCacheable(res *http.Response) bool {
+	if res.StatusCode != http.StatusOK {
+		return false
+	}
+	if strings.Contains(strings.ToLower(res.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+// cacheKey identifies a response by URL. Accept-Encoding is part of the key
+// because requests that set it get the body still compressed.
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept-Encoding")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *cacheTransport) load(key string) (cacheEntry, bool) {
+	data, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// evict removes an entry so the next request for it is unconditional.
+func (t *cacheTransport) evict(key string) {
+	os.Remove(filepath.Join(t.dir, key+".json"))
+	os.Remove(filepath.Join(t.dir, key+".body"))
+}
+
+func (t *cacheTransport) store(key string, entry cacheEntry, body []byte) error {
+	if err := writeFileAtomic(filepath.Join(t.dir, key+".body"), body); err != nil {
+		return err
+	}
+	return t.storeEntry(key, entry)
+}
+
+func (t *cacheTransport) storeEntry(key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("couldn't encode cache entry for %s: %v", entry.URL, err)
+	}
+	return writeFileAtomic(filepath.Join(t.dir, key+".json"), data)
+}
+
+// writeFileAtomic writes through a temporary file so concurrent workers and
+// interrupted crawls never leave a half-written cache file behind.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("couldn't create cache directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("couldn't create cache file: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("couldn't write cache file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("couldn't write cache file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("couldn't write cache file: %v", err)
+	}
+	return nil
+}
+
+// servedFromCache reports whether res is a cached copy revalidated by a 304.
+func servedFromCache(res *http.Response) bool {
+	if res == nil || res.Request == nil {
+		return false
+	}
+	fromCache, _ := res.Request.Context().Value(cacheContextKey{}).(bool)
+	return fromCache
+}
@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheTransport(t *testing.T) {
+	version := "v1"
+	fullResponses := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/etag", func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + version + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		io.WriteString(w, "<html>"+version+"</html>")
+	})
+	mux.HandleFunc("/last-modified", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", "Wed, 01 Jan 2025 00:00:00 GMT")
+		if r.Header.Get("If-Modified-Since") == "Wed, 01 Jan 2025 00:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		io.WriteString(w, "<html>dated</html>")
+	})
+	mux.HandleFunc("/no-store", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"x"`)
+		w.Header().Set("Cache-Control", "no-store")
+		fullResponses++
+		io.WriteString(w, "<html>private</html>")
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"large"`)
+		if r.Header.Get("If-None-Match") == `"large"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		io.WriteString(w, "<html>"+strings.Repeat("x", 64)+"</html>")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	get := func(path string) (string, bool) {
+		// a fresh client per request, as if it were the next crawl
+		client := NewHTTPClient(ClientOptions{CacheDir: cacheDir, MaxCacheEntrySize: 64})
+		res, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected status 200, actual: %d", path, res.StatusCode)
+		}
+		return string(body), servedFromCache(res)
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		before            func()
+		expectedBody      string
+		expectedFromCache bool
+		expectedFull      int
+	}{
+		{name: "first fetch is stored", path: "/etag", expectedBody: "<html>v1</html>", expectedFull: 1},
+		{name: "unchanged page revalidated by ETag", path: "/etag", expectedBody: "<html>v1</html>", expectedFromCache: true, expectedFull: 1},
+		{name: "changed page fetched again", path: "/etag", before: func() { version = "v2" }, expectedBody: "<html>v2</html>", expectedFull: 2},
+		{name: "new version revalidated", path: "/etag", expectedBody: "<html>v2</html>", expectedFromCache: true, expectedFull: 2},
+		{name: "last-modified first fetch", path: "/last-modified", expectedBody: "<html>dated</html>", expectedFull: 3},
+		{name: "revalidated by Last-Modified", path: "/last-modified", expectedBody: "<html>dated</html>", expectedFromCache: true, expectedFull: 3},
+		{name: "no-store not cached", path: "/no-store", expectedBody: "<html>private</html>", expectedFull: 4},
+		{name: "no-store fetched again", path: "/no-store", expectedBody: "<html>private</html>", expectedFull: 5},
+		{name: "over the entry size not cached", path: "/large", expectedBody: "<html>" + strings.Repeat("x", 64) + "</html>", expectedFull: 6},
+		{name: "over the entry size fetched again", path: "/large", expectedBody: "<html>" + strings.Repeat("x", 64) + "</html>", expectedFull: 7},
+		{name: "missing cached body fetched again", path: "/last-modified", before: func() { damageCachedBodies(t, cacheDir, os.Remove) }, expectedBody: "<html>dated</html>", expectedFull: 8},
+		{name: "truncated cached body fetched again", path: "/last-modified", before: func() {
+			damageCachedBodies(t, cacheDir, func(path string) error { return os.Truncate(path, 3) })
+		}, expectedBody: "<html>dated</html>", expectedFull: 9},
+		{name: "refetched page revalidated", path: "/last-modified", expectedBody: "<html>dated</html>", expectedFromCache: true, expectedFull: 9},
+	}
+
+	for i, tc := range tests {
+		if tc.before != nil {
+			tc.before()
+		}
+		body, fromCache := get(tc.path)
+		if body != tc.expectedBody {
+			t.Errorf("Test %v - %s FAIL: expected body %q, actual: %q", i, tc.name, tc.expectedBody, body)
+		}
+		if fromCache != tc.expectedFromCache {
+			t.Errorf("Test %v - %s FAIL: expected from cache %v, actual: %v", i, tc.name, tc.expectedFromCache, fromCache)
+		}
+		if fullResponses != tc.expectedFull {
+			t.Errorf("Test %v - %s FAIL: expected %d full responses, actual: %d", i, tc.name, tc.expectedFull, fullResponses)
+		}
+	}
+}
+
+// damageCachedBodies applies damage to every stored body in cacheDir.
+func damageCachedBodies(t *testing.T, cacheDir string, damage func(path string) error) {
+	paths, _ := filepath.Glob(filepath.Join(cacheDir, "*.body"))
+	for _, path := range paths {
+		if err := damage(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestGetHTMLFromCacheTransferSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, "<html><body>cached page</body></html>")
+	}))
+	defer server.Close()
+
+	cfg := &Config{
+		UserAgent:   "Crawler",
+		HTTPClient:  NewHTTPClient(ClientOptions{CacheDir: t.TempDir()}),
+		MaxBodySize: DefaultMaxBodySize,
+	}
+
+	tests := []struct {
+		name                 string
+		expectedFromCache    bool
+		expectedTransferSize int64
+	}{
+		{name: "full download", expectedTransferSize: 37},
+		{name: "revalidated by a 304", expectedFromCache: true, expectedTransferSize: 0},
+	}
+
+	for i, tc := range tests {
+		htmlRes, err := cfg.getHTML(server.URL)
+		if err != nil {
+			t.Fatalf("Test %v - %s FAIL: unexpected error: %v", i, tc.name, err)
+		}
+		if htmlRes.FromCache != tc.expectedFromCache || htmlRes.TransferSize != tc.expectedTransferSize {
+			t.Errorf("Test %v - %s FAIL: expected: from cache %v, %d bytes, actual: from cache %v, %d bytes", i, tc.name, tc.expectedFromCache, tc.expectedTransferSize, htmlRes.FromCache, htmlRes.TransferSize)
+		}
+		if htmlRes.DecodedSize != 37 {
+			t.Errorf("Test %v - %s FAIL: expected decoded size 37, actual: %d", i, tc.name, htmlRes.DecodedSize)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClientOptions tunes the HTTP client used for every request the crawler
+// makes. A zero timeout means no limit.
+type ClientOptions struct {
+	ConnectTimeout      time.Duration
+	TLSTimeout          time.Duration
+	HeaderTimeout       time.Duration
+	Timeout             time.Duration
+	MaxIdleConnsPerHost int
+	DisableKeepAlives   bool
+	DisableHTTP2        bool
+	TLSMinVersion       uint16
+	InsecureSkipVerify  bool
+	Proxies             *ProxyPool
+	Headers             http.Header
+	Jar                 http.CookieJar
+	Auth                *Auth
+	CacheDir            string
+	MaxCacheEntrySize   int64 // larger responses aren't cached; 0 means DefaultMaxBodySize
+}
+
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		ConnectTimeout:      10 * time.Second,
+		TLSTimeout:          10 * time.Second,
+		HeaderTimeout:       30 * time.Second,
+		Timeout:             60 * time.Second,
+		MaxIdleConnsPerHost: 10,
+		TLSMinVersion:       tls.VersionTLS12,
+	}
+}
+
+func NewHTTPClient(opts ClientOptions) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if opts.DisableKeepAlives {
+		dialer.KeepAlive = -1
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.TLSTimeout,
+		ResponseHeaderTimeout: opts.HeaderTimeout,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		DisableKeepAlives:     opts.DisableKeepAlives,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         opts.TLSMinVersion,
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+		},
+	}
+	if opts.DisableHTTP2 {
+		// a non-nil, empty map is how net/http is told not to negotiate h2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+	}
+	if opts.Proxies != nil && len(opts.Proxies.Proxies) > 0 {
+		transport.Proxy = proxyFromContext
+		client.Transport = &proxyTransport{pool: opts.Proxies, base: transport}
+	}
+	if opts.CacheDir != "" {
+		maxEntrySize := opts.MaxCacheEntrySize
+		if maxEntrySize <= 0 {
+			maxEntrySize = DefaultMaxBodySize
+		}
+		client.Transport = &cacheTransport{dir: opts.CacheDir, maxEntrySize: maxEntrySize, base: client.Transport}
+	}
+	if opts.Auth != nil {
+		client.Transport = &authTransport{auth: opts.Auth, base: client.Transport}
+	}
+	if len(opts.Headers) > 0 {
+		transport := &headerTransport{headers: opts.Headers, base: client.Transport}
+		if opts.Auth != nil {
+			transport.allowHost = opts.Auth.AllowHost
+		}
+		client.Transport = transport
+	}
+	client.Jar = opts.Jar
+	return client
+}
+
+// headerTransport adds the configured headers to requests for hosts that
+// allowHost accepts, or to every request when it is nil. They often carry
+// preview tokens or cookies, so like credentials they stay within the crawl
+// scope. Headers the crawler sets for a specific request take precedence,
+// except Cookie, which is merged with any cookies from the jar.
+type headerTransport struct {
+	headers   http.Header
+	allowHost func(host string) bool
+	base      http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.allowHost != nil && !t.allowHost(req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		switch {
+		case key == "Host":
+			req.Host = values[0]
+		case key == "Cookie" && req.Header.Get("Cookie") != "":
+			req.Header.Set("Cookie", req.Header.Get("Cookie")+"; "+strings.Join(values, "; "))
+		case req.Header.Get(key) == "":
+			req.Header[key] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ParseHeaders parses "Name: value" pairs into a header set.
+func ParseHeaders(rawHeaders []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, rawHeader := range rawHeaders {
+		name, value, ok := strings.Cut(rawHeader, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("couldn't parse header '%s': expected 'Name: value'", rawHeader)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// ParseTLSVersion converts a version such as "1.2" to its crypto/tls constant.
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", version)
+}
+
+// doWithoutRedirects sends req with the configured client but returns
+// redirect responses instead of following them.
+func (cfg *Config) doWithoutRedirects(req *http.Request) (*http.Response, error) {
+	client := *cfg.HTTPClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client.Do(req)
+}
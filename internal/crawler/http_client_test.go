@@ -0,0 +1,175 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClientTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			<-release
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tests := []struct {
+		name string
+		path string
+		opts ClientOptions
+	}{
+		{
+			name: "header timeout",
+			path: "/slow-headers",
+			opts: ClientOptions{HeaderTimeout: 50 * time.Millisecond},
+		},
+		{
+			name: "total timeout while reading body",
+			path: "/slow-body",
+			opts: ClientOptions{Timeout: 50 * time.Millisecond},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewHTTPClient(tc.opts)
+			start := time.Now()
+			res, err := client.Get(server.URL + tc.path)
+			if err == nil {
+				_, err = res.Body.Read(make([]byte, 1))
+				res.Body.Close()
+			}
+			if err == nil {
+				t.Errorf("Test %v - %s FAIL: expected a timeout error", i, tc.name)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Test %v - %s FAIL: request took %v", i, tc.name, elapsed)
+			}
+		})
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      uint16
+		errorContains string
+	}{
+		{input: "1.2", expected: tls.VersionTLS12},
+		{input: "1.3", expected: tls.VersionTLS13},
+		{input: "TLS1.2", errorContains: "unknown TLS version"},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := ParseTLSVersion(tc.input)
+			if tc.errorContains != "" {
+				if err == nil {
+					t.Errorf("Test %v - '%s' FAIL: expected error containing '%v', got none.", i, tc.input, tc.errorContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.input, err)
+				return
+			}
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientHeadersAndCookies(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		}
+	}))
+	defer server.Close()
+
+	headers, err := ParseHeaders([]string{"Accept-Language: de-DE", "X-Preview-Token: t0k3n", "Cookie: preview=yes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jar, _ := NewCookieJar()
+	client := NewHTTPClient(ClientOptions{Headers: headers, Jar: jar})
+
+	for _, path := range []string{"/login", "/page"} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		req.Header.Set("Accept-Language", "fr-FR")
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res.Body.Close()
+	}
+
+	if actual := received.Get("Accept-Language"); actual != "fr-FR" {
+		t.Errorf("expected the request's own header to win, actual: %s", actual)
+	}
+	if actual := received.Get("X-Preview-Token"); actual != "t0k3n" {
+		t.Errorf("expected the configured header to be sent, actual: %s", actual)
+	}
+	if actual := received.Get("Cookie"); actual != "session=s1; preview=yes" {
+		t.Errorf("expected jar and configured cookies to be merged, actual: %s", actual)
+	}
+}
+
+func TestNewHTTPClientHeadersOutOfScope(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	headers, err := ParseHeaders([]string{"X-Preview-Token: secret", "Cookie: preview=yes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewHTTPClient(ClientOptions{
+		Headers: headers,
+		Auth:    &Auth{AllowHost: func(host string) bool { return host == "127.0.0.1" }},
+	})
+
+	tests := []struct {
+		name           string
+		host           string
+		expectedToken  string
+		expectedCookie string
+	}{
+		{name: "in scope", host: "127.0.0.1", expectedToken: "secret", expectedCookie: "preview=yes"},
+		{name: "out of scope", host: "localhost", expectedToken: "", expectedCookie: ""},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			received = nil
+			res, err := client.Get("http://" + tc.host + ":" + serverURL.Port() + "/")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			res.Body.Close()
+
+			if actual := received.Get("X-Preview-Token"); actual != tc.expectedToken {
+				t.Errorf("Test %v - %s FAIL: expected X-Preview-Token: %q, actual: %q", i, tc.name, tc.expectedToken, actual)
+			}
+			if actual := received.Get("Cookie"); actual != tc.expectedCookie {
+				t.Errorf("Test %v - %s FAIL: expected Cookie: %q, actual: %q", i, tc.name, tc.expectedCookie, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LoginForm describes a form login performed before the crawl. Fields are
+// merged over the form's own inputs, so hidden CSRF tokens are kept.
+type LoginForm struct {
+	URL         string
+	Fields      url.Values
+	SuccessText string
+}
+
+// Login fetches the login page, submits its form with the given fields and
+// checks the page it lands on. The session cookies the site sets stay in the
+// client's cookie jar for the rest of the crawl.
+func (cfg *Config) Login(form LoginForm) error {
+	if cfg.HTTPClient.Jar == nil {
+		return fmt.Errorf("form login needs a cookie jar to keep the session")
+	}
+
+	loginURL, err := url.Parse(form.URL)
+	if err != nil {
+		return fmt.Errorf("couldn't parse login URL: %v", err)
+	}
+	if !cfg.Scope.AllowsHost(loginURL.Hostname()) {
+		return fmt.Errorf("refusing to send credentials to %s, which is outside the crawl scope", loginURL.Host)
+	}
+
+	res, body, err := cfg.fetchLoginPage("GET", loginURL.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	// without a form on the page, post the fields straight to the login URL
+	method, actionURL, values := "POST", res.Request.URL, url.Values{}
+	if doc, err := html.Parse(strings.NewReader(body)); err == nil {
+		if formNode := findLoginForm(doc); formNode != nil {
+			method, actionURL, values = loginFormRequest(formNode, res.Request.URL)
+		}
+	}
+	for name, fieldValues := range form.Fields {
+		values[name] = fieldValues
+	}
+	if !cfg.Scope.AllowsHost(actionURL.Hostname()) {
+		return fmt.Errorf("refusing to send credentials to %s, which is outside the crawl scope", actionURL.Host)
+	}
+
+	if method == "GET" {
+		actionURL.RawQuery = values.Encode()
+		res, body, err = cfg.fetchLoginPage("GET", actionURL.String(), nil)
+	} else {
+		res, body, err = cfg.fetchLoginPage("POST", actionURL.String(), values)
+	}
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode > 399 {
+		return fmt.Errorf("login failed: got HTTP error: %s", res.Status)
+	}
+	if form.SuccessText != "" {
+		if !strings.Contains(body, form.SuccessText) {
+			return fmt.Errorf("login failed: %s doesn't contain %q", res.Request.URL, form.SuccessText)
+		}
+	} else if doc, err := html.Parse(strings.NewReader(body)); err == nil && hasPasswordInput(doc) {
+		return fmt.Errorf("login failed: %s still shows a login form", res.Request.URL)
+	}
+	return nil
+}
+
+func (cfg *Config) fetchLoginPage(method, rawURL string, values url.Values) (*http.Response, string, error) {
+	var reqBody io.Reader
+	if values != nil {
+		reqBody = strings.NewReader(values.Encode())
+	}
+	req, err := http.NewRequest(method, rawURL, reqBody)
+	if err != nil {
+		return nil, "", fmt.Errorf("couldn't create login request: %v", err)
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	if values != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	res, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("got Network error during login: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, DefaultMaxBodySize))
+	if err != nil {
+		return nil, "", fmt.Errorf("couldn't read login response: %v", err)
+	}
+	return res, string(body), nil
+}
+
+// findLoginForm returns the first form with a password field, or else the
+// first form on the page.
+func findLoginForm(doc *html.Node) *html.Node {
+	var first, withPassword *html.Node
+	var find func(*html.Node)
+	find = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "form" {
+			if first == nil {
+				first = node
+			}
+			if withPassword == nil && hasPasswordInput(node) {
+				withPassword = node
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			find(child)
+		}
+	}
+	find(doc)
+
+	if withPassword != nil {
+		return withPassword
+	}
+	return first
+}
+
+func hasPasswordInput(node *html.Node) bool {
+	if node.Type == html.ElementNode && node.Data == "input" && strings.EqualFold(getAttr(node, "type"), "password") {
+		return true
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if hasPasswordInput(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// loginFormRequest returns the form's method, resolved action and the
+// default values of its inputs.
+func loginFormRequest(form *html.Node, pageURL *url.URL) (string, *url.URL, url.Values) {
+	method := strings.ToUpper(getAttr(form, "method"))
+	if method != "GET" {
+		method = "POST"
+	}
+
+	actionURL := *pageURL
+	if action := strings.TrimSpace(getAttr(form, "action")); action != "" {
+		if parsed, err := url.Parse(action); err == nil {
+			actionURL = *pageURL.ResolveReference(parsed)
+		}
+	}
+
+	values := url.Values{}
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "input" {
+			name := getAttr(node, "name")
+			inputType := strings.ToLower(getAttr(node, "type"))
+			isChecked := inputType != "checkbox" && inputType != "radio"
+			for _, attr := range node.Attr {
+				if attr.Key == "checked" {
+					isChecked = true
+				}
+			}
+			if name != "" && isChecked && inputType != "submit" && inputType != "button" && inputType != "image" {
+				values.Add(name, getAttr(node, "value"))
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(form)
+
+	return method, &actionURL, values
+}
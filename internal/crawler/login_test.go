@@ -0,0 +1,115 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<html><body>
+			<form action="/search"><input name="q"></form>
+			<form method="post" action="/session">
+				<input type="hidden" name="csrf" value="tok-1">
+				<input name="email">
+				<input type="password" name="password">
+				<input type="checkbox" name="remember" value="1">
+				<input type="submit" name="go" value="Sign in">
+			</form>
+		</body></html>`)
+	})
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != "POST" || r.PostForm.Get("csrf") != "tok-1" || r.PostForm.Get("password") != "s3cret" || r.PostForm.Has("go") || r.PostForm.Has("remember") {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		http.Redirect(w, r, "/account", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s1" {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		io.WriteString(w, "<html><body>Welcome back</body></html>")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		password      string
+		successText   string
+		allowedHost   string
+		errorContains string
+	}{
+		{
+			name:        "success text found",
+			password:    "s3cret",
+			successText: "Welcome back",
+			allowedHost: "127.0.0.1",
+		},
+		{
+			name:        "no success text and no login form",
+			password:    "s3cret",
+			allowedHost: "127.0.0.1",
+		},
+		{
+			name:          "wrong password lands back on the login form",
+			password:      "wrong",
+			allowedHost:   "127.0.0.1",
+			errorContains: "still shows a login form",
+		},
+		{
+			name:          "wrong password with success text",
+			password:      "wrong",
+			successText:   "Welcome back",
+			allowedHost:   "127.0.0.1",
+			errorContains: "doesn't contain",
+		},
+		{
+			name:          "login URL out of scope",
+			password:      "s3cret",
+			allowedHost:   "boot.dev",
+			errorContains: "outside the crawl scope",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jar, _ := NewCookieJar()
+			cfg := &Config{
+				UserAgent:  "Crawler",
+				HTTPClient: NewHTTPClient(ClientOptions{Jar: jar}),
+				Scope:      Scope{AllowedHosts: []string{tc.allowedHost}},
+			}
+
+			err := cfg.Login(LoginForm{
+				URL:         server.URL + "/login",
+				Fields:      url.Values{"email": {"crawler@boot.dev"}, "password": {tc.password}},
+				SuccessText: tc.successText,
+			})
+			if tc.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorContains) {
+					t.Errorf("Test %v - '%s' FAIL: expected error containing '%v', got: %v", i, tc.name, tc.errorContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+
+			serverURL, _ := url.Parse(server.URL)
+			if cookies := jar.Cookies(serverURL); len(cookies) != 1 || cookies[0].Value != "s1" {
+				t.Errorf("Test %v - %s FAIL: expected the session cookie in the jar, actual: %v", i, tc.name, cookies)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package crawler
+
+import (
+	"golang.org/x/net/html"
+)
+
+// PageMetadata is everything the extractors pull out of a page's HTML.
+type PageMetadata struct {
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	MetaDescription      string             `json:"meta_description,omitempty"` // <meta name="description"> only, without fallbacks
+	Keywords             string             `json:"keywords,omitempty"`
+	Author               string             `json:"author,omitempty"`
+	Canonical            string             `json:"canonical,omitempty"`
+	Canonicals           []string           `json:"canonicals,omitempty"` // every canonical link, in document order
+	Language             string             `json:"language,omitempty"`
+	Charset              string             `json:"charset,omitempty"`
+	Robots               string             `json:"robots,omitempty"`
+	OpenGraph            *OpenGraph         `json:"open_graph,omitempty"`
+	Twitter              *TwitterCard       `json:"twitter,omitempty"`
+	Hreflang             []HreflangLink     `json:"hreflang,omitempty"`
+	Headings             []Heading          `json:"headings,omitempty"`
+	ImageCount           int                `json:"image_count,omitempty"`
+	ImagesWithoutAlt     []string           `json:"images_without_alt,omitempty"`
+	WordCount            int                `json:"word_count,omitempty"`
+	StructuredData       []StructuredEntity `json:"structured_data,omitempty"`
+	StructuredDataErrors []string           `json:"structured_data_errors,omitempty"`
+	Custom               map[string]string  `json:"custom,omitempty"`
+}
+
+// SetCustom records a value from a user-registered extractor.
+func (m *PageMetadata) SetCustom(key, value string) {
+	if m.Custom == nil {
+		m.Custom = make(map[string]string)
+	}
+	m.Custom[key] = value
+}
+
+// MetadataExtractor fills in part of a page's metadata from its parsed
+// document. Extractors run in registration order, so later ones can build on
+// what earlier ones found.
+type MetadataExtractor interface {
+	Extract(doc *html.Node, meta *PageMetadata)
+}
+
+// MetadataExtractorFunc adapts a function to a MetadataExtractor.
+type MetadataExtractorFunc func(doc *html.Node, meta *PageMetadata)
+
+func (f MetadataExtractorFunc) Extract(doc *html.Node, meta *PageMetadata) {
+	f(doc, meta)
+}
+
+// ExtractorRegistry is an ordered, named set of metadata extractors. It is
+// set up before the crawl and only read while pages are being crawled.
+type ExtractorRegistry struct {
+	names      []string
+	extractors map[string]MetadataExtractor
+}
+
+func NewExtractorRegistry() *ExtractorRegistry {
+	return &ExtractorRegistry{extractors: make(map[string]MetadataExtractor)}
+}
+
+// DefaultExtractors returns a registry with the built-in extractors.
+func DefaultExtractors() *ExtractorRegistry {
+	r := NewExtractorRegistry()
+	r.Register("head", MetadataExtractorFunc(extractHead))
+	r.Register("open_graph", MetadataExtractorFunc(extractOpenGraph))
+	r.Register("twitter", MetadataExtractorFunc(extractTwitterCard))
+	r.Register("hreflang", MetadataExtractorFunc(extractHreflang))
+	r.Register("content", MetadataExtractorFunc(extractContent))
+	r.Register("description_fallback", MetadataExtractorFunc(fallbackDescription))
+	r.Register("structured_data", MetadataExtractorFunc(extractStructuredData))
+	r.Register("json_ld_description", MetadataExtractorFunc(extractJSONLDDescription))
+	return r
+}
+
+// Register adds an extractor at the end of the run order. Registering an
+// existing name replaces that extractor in place.
+func (r *ExtractorRegistry) Register(name string, extractor MetadataExtractor) {
+	if _, ok := r.extractors[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.extractors[name] = extractor
+}
+
+func (r *ExtractorRegistry) Unregister(name string) {
+	if _, ok := r.extractors[name]; !ok {
+		return
+	}
+	delete(r.extractors, name)
+	for i, n := range r.names {
+		if n == name {
+			r.names = append(r.names[:i], r.names[i+1:]...)
+			break
+		}
+	}
+}
+
+// Names lists the registered extractors in run order.
+func (r *ExtractorRegistry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+func (r *ExtractorRegistry) Extract(doc *html.Node) PageMetadata {
+	var meta PageMetadata
+	for _, name := range r.names {
+		r.extractors[name].Extract(doc, &meta)
+	}
+	return meta
+}
+
+// extractMetadata runs the configured extractors, falling back to the
+// built-in ones for configs that don't set any.
+func (cfg *Config) extractMetadata(doc *html.Node) PageMetadata {
+	extractors := cfg.Extractors
+	if extractors == nil {
+		extractors = DefaultExtractors()
+	}
+	return extractors.Extract(doc)
+}
+
+// walkElements calls fn for every element node under n.
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkElements(c, fn)
+	}
+}
+
+// extractHead reads the <html lang>, <title>, canonical link and standard
+// <meta> tags.
+func extractHead(doc *html.Node, meta *PageMetadata) {
+	walkElements(doc, func(n *html.Node) {
+		switch n.Data {
+		case "html":
+			if lang := getAttr(n, "lang"); lang != "" {
+				meta.Language = lang
+			}
+		case "title":
+			if n.FirstChild != nil {
+				meta.Title = n.FirstChild.Data
+			}
+		case "link":
+			if hasRelToken(getAttr(n, "rel"), "canonical") {
+				href := getAttr(n, "href")
+				if meta.Canonical == "" {
+					meta.Canonical = href
+				}
+				meta.Canonicals = append(meta.Canonicals, href)
+			}
+		case "meta":
+			if charset := getAttr(n, "charset"); charset != "" {
+				meta.Charset = charset
+			}
+			content := getAttr(n, "content")
+			switch getAttr(n, "name") {
+			case "description":
+				meta.Description = content
+				meta.MetaDescription = content
+			case "keywords":
+				meta.Keywords = content
+			case "author":
+				meta.Author = content
+			case "robots":
+				meta.Robots = content
+			}
+		}
+	})
+}
+
+// fallbackDescription uses the Open Graph or Twitter description for pages
+// without a meta description.
+func fallbackDescription(doc *html.Node, meta *PageMetadata) {
+	if meta.Description != "" {
+		return
+	}
+	if meta.OpenGraph != nil && meta.OpenGraph.Description != "" {
+		meta.Description = meta.OpenGraph.Description
+	} else if meta.Twitter != nil && meta.Twitter.Description != "" {
+		meta.Description = meta.Twitter.Description
+	}
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputBody string
+		expected  PageMetadata
+	}{
+		{
+			name: "head tags",
+			inputBody: `<html lang="en"><head><meta charset="utf-8"><title>Boot.dev</title>
+				<meta name="description" content="Learn backend">
+				<meta name="keywords" content="go, python">
+				<meta name="author" content="Lane">
+				<meta name="robots" content="noindex">
+				<link rel="canonical" href="https://blog.boot.dev/"></head></html>`,
+			expected: PageMetadata{
+				Title:           "Boot.dev",
+				Description:     "Learn backend",
+				MetaDescription: "Learn backend",
+				Keywords:        "go, python",
+				Author:          "Lane",
+				Canonical:       "https://blog.boot.dev/",
+				Canonicals:      []string{"https://blog.boot.dev/"},
+				Language:        "en",
+				Charset:         "utf-8",
+				Robots:          "noindex",
+			},
+		},
+		{
+			name: "open graph and twitter",
+			inputBody: `<html><head>
+				<meta property="og:title" content="OG title">
+				<meta property="og:image" content="/og.png">
+				<meta property="og:type" content="article">
+				<meta name="twitter:card" content="summary_large_image">
+				<meta name="twitter:site" content="@bootdotdev"></head></html>`,
+			expected: PageMetadata{
+				OpenGraph: &OpenGraph{Title: "OG title", Images: []OGMedia{{URL: "/og.png"}}, Type: "article"},
+				Twitter:   &TwitterCard{Card: "summary_large_image", Site: "@bootdotdev"},
+			},
+		},
+		{
+			name: "description falls back to open graph",
+			inputBody: `<html><head>
+				<meta name="twitter:description" content="from twitter">
+				<meta property="og:description" content="from og"></head></html>`,
+			expected: PageMetadata{
+				Description: "from og",
+				OpenGraph:   &OpenGraph{Description: "from og"},
+				Twitter:     &TwitterCard{Description: "from twitter"},
+			},
+		},
+		{
+			name: "description falls back to JSON-LD",
+			inputBody: `<html><head><script type="application/ld+json">
+				{"@type": "Article", "description": "from json-ld", "name": "x"}
+				</script></head></html>`,
+			expected: PageMetadata{
+				Description: "from json-ld",
+				StructuredData: []StructuredEntity{
+					{Format: FormatJSONLD, Types: []string{"Article"}, Properties: map[string]any{"description": "from json-ld", "name": "x"}},
+				},
+			},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tc.inputBody))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual := DefaultExtractors().Extract(doc)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExtractorRegistry(t *testing.T) {
+	doc, _ := html.Parse(strings.NewReader(`<html><head><title>Old</title><meta name="generator" content="Hugo"></head></html>`))
+
+	registry := DefaultExtractors()
+	registry.Register("generator", MetadataExtractorFunc(func(doc *html.Node, meta *PageMetadata) {
+		walkElements(doc, func(n *html.Node) {
+			if n.Data == "meta" && getAttr(n, "name") == "generator" {
+				meta.SetCustom("generator", getAttr(n, "content"))
+			}
+		})
+	}))
+	registry.Register("head", MetadataExtractorFunc(func(doc *html.Node, meta *PageMetadata) {
+		meta.Title = "Replaced"
+	}))
+	registry.Unregister("json_ld_description")
+
+	expectedNames := []string{"head", "open_graph", "twitter", "hreflang", "content", "description_fallback", "structured_data", "generator"}
+	if names := registry.Names(); !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("expected extractors %v, actual: %v", expectedNames, names)
+	}
+
+	meta := registry.Extract(doc)
+	if meta.Title != "Replaced" {
+		t.Errorf("expected replaced head extractor to set the title, actual: %q", meta.Title)
+	}
+	if meta.Custom["generator"] != "Hugo" {
+		t.Errorf("expected custom generator Hugo, actual: %v", meta.Custom)
+	}
+}
@@ -3,20 +3,210 @@ package crawler
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 )
 
+// DefaultStripParams lists tracking and session parameters that never
+// identify a distinct page. A trailing * matches any suffix.
+var DefaultStripParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"msclkid",
+	"jsessionid",
+	"phpsessid",
+	"sessionid",
+	"sid",
+}
+
+// URLNormalizer decides which URLs are treated as the same page. The zero
+// value drops the scheme and query string and lowercases the path.
+type URLNormalizer struct {
+	KeepScheme   bool     // treat http and https as distinct pages
+	KeepPathCase bool     // don't lowercase the path
+	KeepQuery    bool     // keep query parameters, subject to KeepParams and StripParams
+	SortQuery    bool     // order query parameters by name
+	KeepParams   []string // when set, only these query parameters are kept
+	StripParams  []string // query and path parameters to drop
+}
+
+func DefaultURLNormalizer() URLNormalizer {
+	return URLNormalizer{StripParams: DefaultStripParams}
+}
+
 func normalizeURL(rawURL string) (string, error) {
+	return DefaultURLNormalizer().Normalize(rawURL)
+}
+
+func (n URLNormalizer) Normalize(rawURL string) (string, error) {
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return "", fmt.Errorf("couldn't parse URL: %w", err)
 	}
 
-	fullPath := parsedURL.Host + parsedURL.Path
+	scheme := strings.ToLower(parsedURL.Scheme)
+	host := strings.ToLower(parsedURL.Host)
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
 
-	fullPath = strings.ToLower(fullPath)
+	path := decodeUnreserved(parsedURL.EscapedPath())
+	path = n.stripPathParams(path)
+	path = removeDotSegments(path)
+	if !n.KeepPathCase {
+		path = strings.ToLower(path)
+	}
 
+	fullPath := host + path
 	fullPath = strings.TrimSuffix(fullPath, "/")
 
+	if n.KeepQuery {
+		if query := n.normalizeQuery(parsedURL.RawQuery); query != "" {
+			fullPath += "?" + query
+		}
+	}
+
+	if n.KeepScheme && scheme != "" {
+		fullPath = scheme + "://" + fullPath
+	}
+
 	return fullPath, nil
 }
+
+func (n URLNormalizer) normalizeQuery(rawQuery string) string {
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(param, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if len(n.KeepParams) > 0 && !matchesParam(n.KeepParams, name) {
+			continue
+		}
+		if matchesParam(n.StripParams, name) {
+			continue
+		}
+		params = append(params, decodeUnreserved(param))
+	}
+
+	if n.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			nameI, _, _ := strings.Cut(params[i], "=")
+			nameJ, _, _ := strings.Cut(params[j], "=")
+			return nameI < nameJ
+		})
+	}
+	return strings.Join(params, "&")
+}
+
+// stripPathParams removes matrix parameters such as ";jsessionid=..." that
+// appear in StripParams.
+func (n URLNormalizer) stripPathParams(path string) string {
+	if !strings.Contains(path, ";") {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		parts := strings.Split(segment, ";")
+		kept := parts[:1]
+		for _, param := range parts[1:] {
+			name, _, _ := strings.Cut(param, "=")
+			if !matchesParam(n.StripParams, name) {
+				kept = append(kept, param)
+			}
+		}
+		segments[i] = strings.Join(kept, ";")
+	}
+	return strings.Join(segments, "/")
+}
+
+func matchesParam(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeUnreserved decodes percent-escapes of unreserved characters
+// (RFC 3986 section 2.3) and uppercases the hex digits of the rest.
+func decodeUnreserved(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// removeDotSegments resolves "." and ".." path segments (RFC 3986 section 5.2.4).
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+
+	var output []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch segment {
+		case ".":
+		case "..":
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+		default:
+			output = append(output, segment)
+			continue
+		}
+		// a trailing dot segment still refers to a directory
+		if i == len(segments)-1 {
+			output = append(output, "")
+		}
+	}
+	return strings.Join(output, "/")
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
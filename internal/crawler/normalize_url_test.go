@@ -60,3 +60,101 @@ func TestNormalizeURL(t *testing.T) {
 		})
 	}
 }
+
+func TestURLNormalizer(t *testing.T) {
+	queryNormalizer := URLNormalizer{KeepQuery: true, SortQuery: true, StripParams: DefaultStripParams}
+
+	tests := []struct {
+		name       string
+		normalizer URLNormalizer
+		inputURL   string
+		expected   string
+	}{
+		{
+			name:       "drop query by default",
+			normalizer: DefaultURLNormalizer(),
+			inputURL:   "https://blog.boot.dev/products?id=1",
+			expected:   "blog.boot.dev/products",
+		},
+		{
+			name:       "keep query",
+			normalizer: queryNormalizer,
+			inputURL:   "https://blog.boot.dev/products?id=1",
+			expected:   "blog.boot.dev/products?id=1",
+		},
+		{
+			name:       "strip tracking parameters",
+			normalizer: queryNormalizer,
+			inputURL:   "https://blog.boot.dev/products?utm_source=x&id=2&fbclid=abc&UTM_Medium=y",
+			expected:   "blog.boot.dev/products?id=2",
+		},
+		{
+			name:       "sort query parameters",
+			normalizer: queryNormalizer,
+			inputURL:   "https://blog.boot.dev/search?q=go&page=2&lang=en",
+			expected:   "blog.boot.dev/search?lang=en&page=2&q=go",
+		},
+		{
+			name:       "keep only listed parameters",
+			normalizer: URLNormalizer{KeepQuery: true, KeepParams: []string{"id"}},
+			inputURL:   "https://blog.boot.dev/products?id=3&view=grid",
+			expected:   "blog.boot.dev/products?id=3",
+		},
+		{
+			name:       "keep path case",
+			normalizer: URLNormalizer{KeepPathCase: true},
+			inputURL:   "https://BLOG.boot.dev/Docs/README",
+			expected:   "blog.boot.dev/Docs/README",
+		},
+		{
+			name:       "remove default port",
+			normalizer: DefaultURLNormalizer(),
+			inputURL:   "https://blog.boot.dev:443/path",
+			expected:   "blog.boot.dev/path",
+		},
+		{
+			name:       "keep non-default port",
+			normalizer: DefaultURLNormalizer(),
+			inputURL:   "http://blog.boot.dev:8080/path",
+			expected:   "blog.boot.dev:8080/path",
+		},
+		{
+			name:       "decode unreserved percent-escapes",
+			normalizer: URLNormalizer{KeepPathCase: true},
+			inputURL:   "https://blog.boot.dev/%7Euser/a%2fb",
+			expected:   "blog.boot.dev/~user/a%2Fb",
+		},
+		{
+			name:       "resolve dot-segments",
+			normalizer: DefaultURLNormalizer(),
+			inputURL:   "https://blog.boot.dev/a/./b/../c",
+			expected:   "blog.boot.dev/a/c",
+		},
+		{
+			name:       "strip session path parameter",
+			normalizer: DefaultURLNormalizer(),
+			inputURL:   "https://blog.boot.dev/cart;jsessionid=ABC123",
+			expected:   "blog.boot.dev/cart",
+		},
+		{
+			name:       "keep scheme",
+			normalizer: URLNormalizer{KeepScheme: true},
+			inputURL:   "HTTP://blog.boot.dev/path/",
+			expected:   "http://blog.boot.dev/path",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.normalizer.Normalize(tc.inputURL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+
+			if actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected URL: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// OpenGraph holds a page's Open Graph properties. Properties the protocol
+// allows more than once are kept as slices in document order.
+type OpenGraph struct {
+	Title            string     `json:"title,omitempty"`
+	Description      string     `json:"description,omitempty"`
+	Type             string     `json:"type,omitempty"`
+	URL              string     `json:"url,omitempty"`
+	SiteName         string     `json:"site_name,omitempty"`
+	Determiner       string     `json:"determiner,omitempty"`
+	Locale           string     `json:"locale,omitempty"`
+	LocaleAlternates []string   `json:"locale_alternates,omitempty"`
+	Images           []OGMedia  `json:"images,omitempty"`
+	Videos           []OGMedia  `json:"videos,omitempty"`
+	Audio            []OGMedia  `json:"audio,omitempty"`
+	Article          *OGArticle `json:"article,omitempty"`
+	// Properties keeps every other og:*, book:*, profile:*, music:* and
+	// video:* property, repeated values included.
+	Properties map[string][]string `json:"properties,omitempty"`
+}
+
+// OGMedia is an og:image, og:video or og:audio with its structured
+// properties. Width and Height are kept as written so invalid values can be
+// reported.
+type OGMedia struct {
+	URL       string `json:"url,omitempty"`
+	SecureURL string `json:"secure_url,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Width     string `json:"width,omitempty"`
+	Height    string `json:"height,omitempty"`
+	Alt       string `json:"alt,omitempty"`
+}
+
+type OGArticle struct {
+	PublishedTime  string   `json:"published_time,omitempty"`
+	ModifiedTime   string   `json:"modified_time,omitempty"`
+	ExpirationTime string   `json:"expiration_time,omitempty"`
+	Authors        []string `json:"authors,omitempty"`
+	Section        string   `json:"section,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+}
+
+type TwitterCard struct {
+	Card         string `json:"card,omitempty"`
+	Site         string `json:"site,omitempty"`
+	SiteID       string `json:"site_id,omitempty"`
+	Creator      string `json:"creator,omitempty"`
+	CreatorID    string `json:"creator_id,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Image        string `json:"image,omitempty"`
+	ImageAlt     string `json:"image_alt,omitempty"`
+	Player       string `json:"player,omitempty"`
+	PlayerWidth  string `json:"player_width,omitempty"`
+	PlayerHeight string `json:"player_height,omitempty"`
+	PlayerStream string `json:"player_stream,omitempty"`
+	// Properties keeps the app:* properties and anything else not listed above.
+	Properties map[string][]string `json:"properties,omitempty"`
+}
+
+// metaProperty returns the property of a <meta> tag with the given prefix.
+// Open Graph uses property= and Twitter name=, but sites mix them up often
+// enough that both are accepted.
+func metaProperty(n *html.Node, prefixes ...string) string {
+	for _, key := range []string{"property", "name"} {
+		value := strings.TrimSpace(getAttr(n, key))
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(value, prefix) {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+func extractOpenGraph(doc *html.Node, meta *PageMetadata) {
+	og := &OpenGraph{}
+	found := false
+	walkElements(doc, func(n *html.Node) {
+		if n.Data != "meta" {
+			return
+		}
+		property := metaProperty(n, "og:", "article:", "book:", "profile:", "music:", "video:")
+		if property == "" {
+			return
+		}
+		og.add(property, strings.TrimSpace(getAttr(n, "content")))
+		found = true
+	})
+	if found {
+		meta.OpenGraph = og
+	}
+}
+
+func (og *OpenGraph) add(property, content string) {
+	switch property {
+	case "og:title":
+		og.Title = content
+	case "og:description":
+		og.Description = content
+	case "og:type":
+		og.Type = content
+	case "og:url":
+		og.URL = content
+	case "og:site_name":
+		og.SiteName = content
+	case "og:determiner":
+		og.Determiner = content
+	case "og:locale":
+		og.Locale = content
+	case "og:locale:alternate":
+		og.LocaleAlternates = append(og.LocaleAlternates, content)
+	default:
+		if kind, field, ok := ogMediaProperty(property); ok {
+			switch kind {
+			case "image":
+				og.Images = addMedia(og.Images, field, content)
+			case "video":
+				og.Videos = addMedia(og.Videos, field, content)
+			case "audio":
+				og.Audio = addMedia(og.Audio, field, content)
+			}
+			return
+		}
+		if field, ok := strings.CutPrefix(property, "article:"); ok && og.addArticle(field, content) {
+			return
+		}
+		if og.Properties == nil {
+			og.Properties = make(map[string][]string)
+		}
+		og.Properties[property] = append(og.Properties[property], content)
+	}
+}
+
+// ogMediaProperty splits og:image:width into "image" and "width"; a bare
+// og:image has an empty field.
+func ogMediaProperty(property string) (kind, field string, ok bool) {
+	rest, ok := strings.CutPrefix(property, "og:")
+	if !ok {
+		return "", "", false
+	}
+	kind, field, _ = strings.Cut(rest, ":")
+	return kind, field, kind == "image" || kind == "video" || kind == "audio"
+}
+
+// addMedia applies og:image (field "") or one of its structured properties
+// such as og:image:width. The structured properties describe the most recent
+// image, and a new og:image starts the next one.
+func addMedia(list []OGMedia, field, content string) []OGMedia {
+	startsNew := field == "" || field == "url" && len(list) > 0 && list[len(list)-1].URL != "" && list[len(list)-1].URL != content
+	if startsNew || len(list) == 0 {
+		list = append(list, OGMedia{})
+	}
+	media := &list[len(list)-1]
+	switch field {
+	case "", "url":
+		media.URL = content
+	case "secure_url":
+		media.SecureURL = content
+	case "type":
+		media.Type = content
+	case "width":
+		media.Width = content
+	case "height":
+		media.Height = content
+	case "alt":
+		media.Alt = content
+	}
+	return list
+}
+
+func (og *OpenGraph) addArticle(field, content string) bool {
+	article := og.Article
+	if article == nil {
+		article = &OGArticle{}
+	}
+	switch field {
+	case "published_time":
+		article.PublishedTime = content
+	case "modified_time":
+		article.ModifiedTime = content
+	case "expiration_time":
+		article.ExpirationTime = content
+	case "author":
+		article.Authors = append(article.Authors, content)
+	case "section":
+		article.Section = content
+	case "tag":
+		article.Tags = append(article.Tags, content)
+	default:
+		return false
+	}
+	og.Article = article
+	return true
+}
+
+func extractTwitterCard(doc *html.Node, meta *PageMetadata) {
+	card := &TwitterCard{}
+	found := false
+	walkElements(doc, func(n *html.Node) {
+		if n.Data != "meta" {
+			return
+		}
+		property := metaProperty(n, "twitter:")
+		if property == "" {
+			return
+		}
+		card.add(strings.TrimPrefix(property, "twitter:"), strings.TrimSpace(getAttr(n, "content")))
+		found = true
+	})
+	if found {
+		meta.Twitter = card
+	}
+}
+
+func (card *TwitterCard) add(name, content string) {
+	switch name {
+	case "card":
+		card.Card = content
+	case "site":
+		card.Site = content
+	case "site:id":
+		card.SiteID = content
+	case "creator":
+		card.Creator = content
+	case "creator:id":
+		card.CreatorID = content
+	case "title":
+		card.Title = content
+	case "description":
+		card.Description = content
+	case "image", "image:src":
+		card.Image = content
+	case "image:alt":
+		card.ImageAlt = content
+	case "player":
+		card.Player = content
+	case "player:width":
+		card.PlayerWidth = content
+	case "player:height":
+		card.PlayerHeight = content
+	case "player:stream":
+		card.PlayerStream = content
+	default:
+		if card.Properties == nil {
+			card.Properties = make(map[string][]string)
+		}
+		card.Properties["twitter:"+name] = append(card.Properties["twitter:"+name], content)
+	}
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractOpenGraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputBody string
+		expected  *OpenGraph
+	}{
+		{
+			name: "repeated images with structured properties",
+			inputBody: `<meta property="og:image" content="https://blog.boot.dev/a.png">
+				<meta property="og:image:width" content="1200">
+				<meta property="og:image:height" content="630">
+				<meta property="og:image:alt" content="A">
+				<meta property="og:image" content="https://blog.boot.dev/b.png">
+				<meta property="og:image:secure_url" content="https://cdn.boot.dev/b.png">
+				<meta property="og:image:type" content="image/png">`,
+			expected: &OpenGraph{Images: []OGMedia{
+				{URL: "https://blog.boot.dev/a.png", Width: "1200", Height: "630", Alt: "A"},
+				{URL: "https://blog.boot.dev/b.png", SecureURL: "https://cdn.boot.dev/b.png", Type: "image/png"},
+			}},
+		},
+		{
+			name: "og:image:url describes the current image",
+			inputBody: `<meta property="og:image" content="https://blog.boot.dev/a.png">
+				<meta property="og:image:url" content="https://blog.boot.dev/a.png">
+				<meta property="og:image:url" content="https://blog.boot.dev/b.png">`,
+			expected: &OpenGraph{Images: []OGMedia{
+				{URL: "https://blog.boot.dev/a.png"},
+				{URL: "https://blog.boot.dev/b.png"},
+			}},
+		},
+		{
+			name: "locales, video and audio",
+			inputBody: `<meta property="og:locale" content="en_US">
+				<meta property="og:locale:alternate" content="fr_FR">
+				<meta property="og:locale:alternate" content="de_DE">
+				<meta property="og:video" content="https://blog.boot.dev/v.mp4">
+				<meta property="og:video:width" content="640">
+				<meta property="og:audio" content="https://blog.boot.dev/a.mp3">
+				<meta property="og:determiner" content="the">`,
+			expected: &OpenGraph{
+				Locale:           "en_US",
+				LocaleAlternates: []string{"fr_FR", "de_DE"},
+				Videos:           []OGMedia{{URL: "https://blog.boot.dev/v.mp4", Width: "640"}},
+				Audio:            []OGMedia{{URL: "https://blog.boot.dev/a.mp3"}},
+				Determiner:       "the",
+			},
+		},
+		{
+			name: "article and other type properties",
+			inputBody: `<meta property="og:type" content="article">
+				<meta property="article:published_time" content="2025-01-02T03:04:05Z">
+				<meta property="article:author" content="https://blog.boot.dev/lane">
+				<meta property="article:author" content="https://blog.boot.dev/allan">
+				<meta property="article:tag" content="go">
+				<meta property="article:tag" content="http">
+				<meta property="article:section" content="Backend">
+				<meta property="book:isbn" content="978-3-16-148410-0">
+				<meta name="og:title" content="Set with name=">`,
+			expected: &OpenGraph{
+				Title: "Set with name=",
+				Type:  "article",
+				Article: &OGArticle{
+					PublishedTime: "2025-01-02T03:04:05Z",
+					Authors:       []string{"https://blog.boot.dev/lane", "https://blog.boot.dev/allan"},
+					Section:       "Backend",
+					Tags:          []string{"go", "http"},
+				},
+				Properties: map[string][]string{"book:isbn": {"978-3-16-148410-0"}},
+			},
+		},
+		{
+			name:      "no Open Graph tags",
+			inputBody: `<meta name="description" content="plain">`,
+			expected:  nil,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><head>" + tc.inputBody + "</head></html>"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var meta PageMetadata
+			extractOpenGraph(doc, &meta)
+			if !reflect.DeepEqual(meta.OpenGraph, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expected, meta.OpenGraph)
+			}
+		})
+	}
+}
+
+func TestExtractTwitterCard(t *testing.T) {
+	doc, _ := html.Parse(strings.NewReader(`<html><head>
+		<meta name="twitter:card" content="summary_large_image">
+		<meta name="twitter:site" content="@bootdotdev">
+		<meta name="twitter:creator" content="@wagslane">
+		<meta name="twitter:image:src" content="https://blog.boot.dev/card.png">
+		<meta name="twitter:image:alt" content="Card">
+		<meta property="twitter:title" content="Set with property=">
+		<meta name="twitter:app:id:iphone" content="123">
+	</head></html>`))
+
+	var meta PageMetadata
+	extractTwitterCard(doc, &meta)
+	expected := &TwitterCard{
+		Card:       "summary_large_image",
+		Site:       "@bootdotdev",
+		Creator:    "@wagslane",
+		Title:      "Set with property=",
+		Image:      "https://blog.boot.dev/card.png",
+		ImageAlt:   "Card",
+		Properties: map[string][]string{"twitter:app:id:iphone": {"123"}},
+	}
+	if !reflect.DeepEqual(meta.Twitter, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, meta.Twitter)
+	}
+}
@@ -3,38 +3,70 @@ package crawler
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 )
 
 type Page struct {
-	URL          string          `json:"url"`
-	Count        int             `json:"count"`
-	Title        string          `json:"title,omitempty"`
-	Description  string          `json:"description,omitempty"`
-	Keywords     string          `json:"keywords,omitempty"`
-	Author       string          `json:"author,omitempty"`
-	Canonical    string          `json:"canonical,omitempty"`
-	Language     string          `json:"language,omitempty"`
-	Charset      string          `json:"charset,omitempty"`
-	OGImage      string          `json:"og_image,omitempty"`
-	OGType       string          `json:"og_type,omitempty"`
-	OGURL        string          `json:"og_url,omitempty"`
-	OGSiteName   string          `json:"og_site_name,omitempty"`
-	TwitterCard  string          `json:"twitter_card,omitempty"`
-	TwitterSite  string          `json:"twitter_site,omitempty"`
-	TwitterImage string          `json:"twitter_image,omitempty"`
-	Suggestions  *AnalysisResult `json:"suggestions,omitempty"`
+	PageMetadata
+	URL               string          `json:"url"`
+	Count             int             `json:"count"`
+	StatusCode        int             `json:"status_code,omitempty"`
+	ContentType       string          `json:"content_type,omitempty"`
+	FinalURL          string          `json:"final_url,omitempty"`
+	Redirects         []RedirectHop   `json:"redirects,omitempty"`
+	LastModified      string          `json:"last_modified,omitempty"`
+	Truncated         bool            `json:"truncated,omitempty"`
+	FromCache         bool            `json:"from_cache,omitempty"`
+	ContentEncoding   string          `json:"content_encoding,omitempty"`
+	TransferSize      int64           `json:"transfer_size,omitempty"`
+	DecodedSize       int64           `json:"decoded_size,omitempty"`
+	HeaderCharset     string          `json:"header_charset,omitempty"`
+	DetectedCharset   string          `json:"detected_charset,omitempty"`
+	CharsetMismatch   string          `json:"charset_mismatch,omitempty"`
+	XRobotsTag        string          `json:"x_robots_tag,omitempty"`
+	HeaderCanonical   string          `json:"header_canonical,omitempty"`
+	Proxy             string          `json:"proxy,omitempty"`
+	SitemapLastMod    string          `json:"sitemap_lastmod,omitempty"`
+	SitemapChangeFreq string          `json:"sitemap_changefreq,omitempty"`
+	SitemapPriority   string          `json:"sitemap_priority,omitempty"`
+	Assets            []Link          `json:"assets,omitempty"`
+	Suggestions       *AnalysisResult `json:"suggestions,omitempty"`
 }
 
-func PrintReport(pages map[string]*PageData, baseURL string, jsonOutput bool, outputFile string) {
+// Report holds the per-page results plus any optional site-level audits.
+type Report struct {
+	BaseURL          string                 `json:"base_url"`
+	Pages            []Page                 `json:"pages"`
+	SitemapCoverage  *SitemapCoverage       `json:"sitemap_coverage,omitempty"`
+	ExternalLinks    []ExternalLink         `json:"external_links,omitempty"`
+	Redirects        *RedirectAudit         `json:"redirects,omitempty"`
+	Assets           *AssetReport           `json:"assets,omitempty"`
+	SocialPreviews   *SocialPreviewAudit    `json:"social_previews,omitempty"`
+	StructuredData   *StructuredDataSummary `json:"structured_data,omitempty"`
+	SchemaValidation *SchemaValidation      `json:"schema_validation,omitempty"`
+	Hreflang         *HreflangAudit         `json:"hreflang,omitempty"`
+	Canonicals       *CanonicalAudit        `json:"canonicals,omitempty"`
+	SEO              *SEOAudit              `json:"seo,omitempty"`
+	Duplicates       *DuplicateContent      `json:"duplicates,omitempty"`
+}
+
+func NewReport(pages map[string]*PageData, baseURL string) *Report {
+	return &Report{
+		BaseURL: baseURL,
+		Pages:   sortPages(pages),
+	}
+}
+
+func PrintReport(report *Report, jsonOutput bool, outputFile string) {
 	if outputFile != "" {
-		saveReportToFile(pages, outputFile, jsonOutput)
+		saveReportToFile(report, outputFile, jsonOutput)
 		return
 	}
 
 	if jsonOutput {
-		printJSONReport(pages)
+		printJSONReport(report)
 		return
 	}
 
@@ -42,17 +74,12 @@ func PrintReport(pages map[string]*PageData, baseURL string, jsonOutput bool, ou
 =============================
   REPORT for %s
 =============================
-`, baseURL)
+`, report.BaseURL)
 
-	sortedPages := sortPages(pages)
-	for _, page := range sortedPages {
-		url := page.URL
-		count := page.Count
-		fmt.Printf("Found %d internal links to %s\n", count, url)
-	}
+	writeTextReport(os.Stdout, report)
 }
 
-func saveReportToFile(pages map[string]*PageData, outputFile string, jsonOutput bool) {
+func saveReportToFile(report *Report, outputFile string, jsonOutput bool) {
 	f, err := os.Create(outputFile)
 	if err != nil {
 		fmt.Printf("Error creating file: %v\n", err)
@@ -60,27 +87,21 @@ func saveReportToFile(pages map[string]*PageData, outputFile string, jsonOutput
 	}
 	defer f.Close()
 
-	sortedPages := sortPages(pages)
-
 	if jsonOutput {
-		jsonData, err := json.MarshalIndent(sortedPages, "", "  ")
+		jsonData, err := json.MarshalIndent(report.jsonValue(), "", "  ")
 		if err != nil {
 			fmt.Printf("Error marshalling JSON: %v\n", err)
 			return
 		}
 		f.Write(jsonData)
 	} else {
-		for _, page := range sortedPages {
-			line := fmt.Sprintf("Found %d internal links to %s\n", page.Count, page.URL)
-			f.WriteString(line)
-		}
+		writeTextReport(f, report)
 	}
 	fmt.Printf("Report saved to %s\n", outputFile)
 }
 
-func printJSONReport(pages map[string]*PageData) {
-	sortedPages := sortPages(pages)
-	jsonData, err := json.MarshalIndent(sortedPages, "", "  ")
+func printJSONReport(report *Report) {
+	jsonData, err := json.MarshalIndent(report.jsonValue(), "", "  ")
 	if err != nil {
 		fmt.Printf("Error marshalling JSON: %v\n", err)
 		return
@@ -88,27 +109,97 @@ func printJSONReport(pages map[string]*PageData) {
 	fmt.Println(string(jsonData))
 }
 
+// jsonValue keeps the JSON report the plain array of pages it has always
+// been unless a site-level audit was requested.
+func (report *Report) jsonValue() any {
+	if report.SitemapCoverage == nil && report.ExternalLinks == nil && report.Redirects == nil &&
+		report.Assets == nil && report.SocialPreviews == nil && report.StructuredData == nil &&
+		report.SchemaValidation == nil && report.Hreflang == nil && report.Canonicals == nil &&
+		report.SEO == nil && report.Duplicates == nil {
+		return report.Pages
+	}
+	return report
+}
+
+func writeTextReport(w io.Writer, report *Report) {
+	for _, page := range report.Pages {
+		fmt.Fprintf(w, "Found %d internal links to %s\n", page.Count, page.URL)
+	}
+	writeTruncatedPagesText(w, report.Pages)
+	writeCharsetMismatchesText(w, report.Pages)
+	writeUncompressedPagesText(w, report.Pages)
+
+	if report.SitemapCoverage != nil {
+		report.SitemapCoverage.writeText(w)
+	}
+	if report.Redirects != nil {
+		report.Redirects.writeText(w)
+	}
+	if report.ExternalLinks != nil {
+		writeExternalLinksText(w, report.ExternalLinks)
+	}
+	if report.Assets != nil {
+		report.Assets.writeText(w)
+	}
+	if report.SocialPreviews != nil {
+		report.SocialPreviews.writeText(w)
+	}
+	if report.StructuredData != nil {
+		report.StructuredData.writeText(w)
+	}
+	if report.SchemaValidation != nil {
+		report.SchemaValidation.writeText(w)
+	}
+	if report.Hreflang != nil {
+		report.Hreflang.writeText(w)
+	}
+	if report.Canonicals != nil {
+		report.Canonicals.writeText(w)
+	}
+	if report.SEO != nil {
+		report.SEO.writeText(w)
+	}
+	if report.Duplicates != nil {
+		report.Duplicates.writeText(w)
+	}
+}
+
+func writeSectionHeader(w io.Writer, title string) {
+	fmt.Fprintf(w, `
+=============================
+  %s
+=============================
+`, title)
+}
+
 func sortPages(pages map[string]*PageData) []Page {
 	pagesSlice := []Page{}
-	for url, data := range pages {
+	for _, data := range pages {
 		pagesSlice = append(pagesSlice, Page{
-			URL:          url,
-			Count:        data.LinkCount,
-			Title:        data.Title,
-			Description:  data.Description,
-			Keywords:     data.Keywords,
-			Author:       data.Author,
-			Canonical:    data.Canonical,
-			Language:     data.Language,
-			Charset:      data.Charset,
-			OGImage:      data.OGImage,
-			OGType:       data.OGType,
-			OGURL:        data.OGURL,
-			OGSiteName:   data.OGSiteName,
-			TwitterCard:  data.TwitterCard,
-			TwitterSite:  data.TwitterSite,
-			TwitterImage: data.TwitterImage,
-			Suggestions:  data.Suggestions,
+			URL:               data.URL,
+			Count:             data.LinkCount,
+			PageMetadata:      data.PageMetadata,
+			StatusCode:        data.StatusCode,
+			ContentType:       data.ContentType,
+			FinalURL:          data.FinalURL,
+			Redirects:         data.Redirects,
+			LastModified:      data.LastModified,
+			Truncated:         data.Truncated,
+			FromCache:         data.FromCache,
+			ContentEncoding:   data.ContentEncoding,
+			TransferSize:      data.TransferSize,
+			DecodedSize:       data.DecodedSize,
+			HeaderCharset:     data.HeaderCharset,
+			DetectedCharset:   data.DetectedCharset,
+			CharsetMismatch:   data.CharsetMismatch,
+			XRobotsTag:        data.XRobotsTag,
+			HeaderCanonical:   data.HeaderCanonical,
+			Proxy:             data.Proxy,
+			SitemapLastMod:    data.SitemapLastMod,
+			SitemapChangeFreq: data.SitemapChangeFreq,
+			SitemapPriority:   data.SitemapPriority,
+			Assets:            data.Assets,
+			Suggestions:       data.Suggestions,
 		})
 	}
 	sort.Slice(pagesSlice, func(i, j int) bool {
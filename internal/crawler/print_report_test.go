@@ -14,11 +14,11 @@ func TestSortPages(t *testing.T) {
 		{
 			name: "order count descending",
 			input: map[string]*PageData{
-				"url1": {LinkCount: 5},
-				"url2": {LinkCount: 1},
-				"url3": {LinkCount: 3},
-				"url4": {LinkCount: 10},
-				"url5": {LinkCount: 7},
+				"url1": {URL: "url1", LinkCount: 5},
+				"url2": {URL: "url2", LinkCount: 1},
+				"url3": {URL: "url3", LinkCount: 3},
+				"url4": {URL: "url4", LinkCount: 10},
+				"url5": {URL: "url5", LinkCount: 7},
 			},
 			expected: []Page{
 				{URL: "url4", Count: 10},
@@ -31,11 +31,11 @@ func TestSortPages(t *testing.T) {
 		{
 			name: "alphabetize",
 			input: map[string]*PageData{
-				"d": {LinkCount: 1},
-				"a": {LinkCount: 1},
-				"e": {LinkCount: 1},
-				"b": {LinkCount: 1},
-				"c": {LinkCount: 1},
+				"d": {URL: "d", LinkCount: 1},
+				"a": {URL: "a", LinkCount: 1},
+				"e": {URL: "e", LinkCount: 1},
+				"b": {URL: "b", LinkCount: 1},
+				"c": {URL: "c", LinkCount: 1},
 			},
 			expected: []Page{
 				{URL: "a", Count: 1},
@@ -48,11 +48,11 @@ func TestSortPages(t *testing.T) {
 		{
 			name: "order count then alphabetize",
 			input: map[string]*PageData{
-				"d": {LinkCount: 2},
-				"a": {LinkCount: 1},
-				"e": {LinkCount: 3},
-				"b": {LinkCount: 1},
-				"c": {LinkCount: 2},
+				"d": {URL: "d", LinkCount: 2},
+				"a": {URL: "a", LinkCount: 1},
+				"e": {URL: "e", LinkCount: 3},
+				"b": {URL: "b", LinkCount: 1},
+				"c": {URL: "c", LinkCount: 2},
 			},
 			expected: []Page{
 				{URL: "e", Count: 3},
@@ -75,12 +75,21 @@ func TestSortPages(t *testing.T) {
 		{
 			name: "one key",
 			input: map[string]*PageData{
-				"url1": {LinkCount: 1},
+				"url1": {URL: "url1", LinkCount: 1},
 			},
 			expected: []Page{
 				{URL: "url1", Count: 1},
 			},
 		},
+		{
+			name: "crawled URL rather than the normalized key",
+			input: map[string]*PageData{
+				"blog.boot.dev/path": {URL: "https://blog.boot.dev/path/", LinkCount: 1},
+			},
+			expected: []Page{
+				{URL: "https://blog.boot.dev/path/", Count: 1},
+			},
+		},
 	}
 
 	for i, tc := range tests {
@@ -92,3 +101,28 @@ func TestSortPages(t *testing.T) {
 		})
 	}
 }
+
+func TestReportJSONValue(t *testing.T) {
+	pages := map[string]*PageData{"blog.boot.dev": {URL: "https://blog.boot.dev", LinkCount: 1}}
+
+	tests := []struct {
+		name          string
+		audit         func(*Report)
+		expectedPages bool
+	}{
+		{name: "pages only", audit: func(*Report) {}, expectedPages: true},
+		{name: "sitemap coverage requested", audit: func(report *Report) { report.SitemapCoverage = &SitemapCoverage{} }},
+		{name: "SEO audit requested", audit: func(report *Report) { report.SEO = &SEOAudit{} }},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report := NewReport(pages, "https://blog.boot.dev")
+			tc.audit(report)
+			_, isPages := report.jsonValue().([]Page)
+			if isPages != tc.expectedPages {
+				t.Errorf("Test %v - %s FAIL: expected array of pages: %v, actual: %v", i, tc.name, tc.expectedPages, isPages)
+			}
+		})
+	}
+}
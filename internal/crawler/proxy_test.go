@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newStandInProxy returns a plain HTTP forward proxy that answers every
+// request itself with its name, recording the Proxy-Authorization it saw.
+func newStandInProxy(name string, auth *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*auth = r.Header.Get("Proxy-Authorization")
+		io.WriteString(w, name+" "+r.URL.String())
+	}))
+}
+
+func TestProxyPool(t *testing.T) {
+	var authA, authB string
+	proxyA := newStandInProxy("a", &authA)
+	defer proxyA.Close()
+	proxyB := newStandInProxy("b", &authB)
+	defer proxyB.Close()
+
+	proxyAURL, _ := url.Parse(proxyA.URL)
+	proxyAURL.User = url.UserPassword("crawler", "secret")
+
+	tests := []struct {
+		name          string
+		perHost       bool
+		targets       []string
+		expectedNames []string
+	}{
+		{
+			name:          "round robin",
+			targets:       []string{"http://one.test/", "http://one.test/a", "http://one.test/b"},
+			expectedNames: []string{"a", "b", "a"},
+		},
+		{
+			name:    "per host",
+			perHost: true,
+			targets: []string{"http://one.test/", "http://one.test/a", "http://one.test/b"},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool, err := NewProxyPool([]string{proxyAURL.String(), proxyB.URL}, tc.perHost)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			client := NewHTTPClient(ClientOptions{Proxies: pool})
+
+			var names []string
+			for _, target := range tc.targets {
+				res, err := client.Get(target)
+				if err != nil {
+					t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+					return
+				}
+				body, _ := io.ReadAll(res.Body)
+				res.Body.Close()
+
+				name, servedURL, _ := strings.Cut(string(body), " ")
+				if servedURL != target {
+					t.Errorf("Test %v - %s FAIL: expected proxied request for %s, actual: %s", i, tc.name, target, servedURL)
+				}
+				expectedProxy := proxyB.URL
+				if name == "a" {
+					expectedProxy = proxyAURL.Redacted()
+				}
+				if actual := proxyUsed(res); actual != expectedProxy {
+					t.Errorf("Test %v - %s FAIL: expected proxy %s, actual: %s", i, tc.name, expectedProxy, actual)
+				}
+				names = append(names, name)
+			}
+
+			if tc.perHost {
+				for _, name := range names {
+					if name != names[0] {
+						t.Errorf("Test %v - %s FAIL: expected one proxy for the host, actual: %v", i, tc.name, names)
+						break
+					}
+				}
+				return
+			}
+			if strings.Join(names, ",") != strings.Join(tc.expectedNames, ",") {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expectedNames, names)
+			}
+		})
+	}
+
+	if authA != "Basic Y3Jhd2xlcjpzZWNyZXQ=" {
+		t.Errorf("expected proxy a to receive credentials, actual: %q", authA)
+	}
+	if authB != "" {
+		t.Errorf("expected proxy b to receive no credentials, actual: %q", authB)
+	}
+}
+
+func TestNewProxyPoolRejectsUnknownScheme(t *testing.T) {
+	if _, err := NewProxyPool([]string{"ftp://proxy:21"}, false); err == nil {
+		t.Errorf("expected an error for an ftp proxy")
+	}
+}
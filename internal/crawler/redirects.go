@@ -0,0 +1,197 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// redirectHopsFrom returns the hops a request for rawURL goes through, given
+// the page crawled under its key. A redirect that only changed something
+// normalisation ignores is stored under the key of the page it ended on, so
+// it only counts when rawURL is one of the URLs that actually redirected.
+func redirectHopsFrom(rawURL string, data *PageData) []RedirectHop {
+	if data.FinalURL != "" {
+		return data.Redirects
+	}
+	for i, hop := range data.Redirects {
+		if hop.URL == rawURL {
+			return data.Redirects[i:]
+		}
+	}
+	return nil
+}
+
+// followRedirects issues a GET for rawURL and follows redirects by hand so
+// every hop is recorded. The final response body is left open for the caller.
+func (cfg *Config) followRedirects(rawURL string) (*http.Response, []RedirectHop, error) {
+	var hops []RedirectHop
+	seen := map[string]bool{}
+	currentURL := rawURL
+	for {
+		seen[currentURL] = true
+
+		req, err := http.NewRequest("GET", currentURL, nil)
+		if err != nil {
+			return nil, hops, fmt.Errorf("got Network error: %v", err)
+		}
+		req.Header.Set("User-Agent", cfg.UserAgent)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+
+		res, err := cfg.doWithoutRedirects(req)
+		if err != nil {
+			return nil, hops, fmt.Errorf("got Network error: %v", err)
+		}
+
+		location, err := res.Location()
+		if res.StatusCode < 300 || res.StatusCode > 399 || err != nil {
+			return res, hops, nil
+		}
+		res.Body.Close()
+
+		hops = append(hops, RedirectHop{
+			URL:        currentURL,
+			StatusCode: res.StatusCode,
+			Location:   location.String(),
+		})
+		if seen[location.String()] {
+			return nil, hops, fmt.Errorf("redirect loop at %s", location)
+		}
+		if len(hops) == maxRedirects {
+			return nil, hops, fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		currentURL = location.String()
+	}
+}
+
+type RedirectChain struct {
+	URL       string        `json:"url"`
+	FinalURL  string        `json:"final_url,omitempty"`
+	Hops      []RedirectHop `json:"hops"`
+	Referrers []string      `json:"referrers,omitempty"`
+}
+
+// RedirectAudit lists the redirects met during the crawl that need fixing.
+type RedirectAudit struct {
+	ChainLimit      int             `json:"chain_limit"`
+	LongChains      []RedirectChain `json:"long_chains"`
+	Loops           []RedirectChain `json:"loops"`
+	Temporary       []RedirectChain `json:"temporary"`
+	LinkedRedirects []RedirectChain `json:"linked_redirects"`
+}
+
+func (chain RedirectChain) isLoop() bool {
+	last := chain.Hops[len(chain.Hops)-1]
+	for _, hop := range chain.Hops {
+		if hop.URL == last.Location {
+			return true
+		}
+	}
+	return false
+}
+
+func (chain RedirectChain) isTemporary() bool {
+	for _, hop := range chain.Hops {
+		if hop.StatusCode == http.StatusFound || hop.StatusCode == http.StatusTemporaryRedirect {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditRedirects reports redirect chains with more than chainLimit hops,
+// redirect loops, temporary redirects that are probably meant to be
+// permanent, and internal links that point at a redirect.
+func (cfg *Config) AuditRedirects(chainLimit int) *RedirectAudit {
+	audit := &RedirectAudit{
+		ChainLimit:      chainLimit,
+		LongChains:      []RedirectChain{},
+		Loops:           []RedirectChain{},
+		Temporary:       []RedirectChain{},
+		LinkedRedirects: []RedirectChain{},
+	}
+
+	cfg.Mu.Lock()
+	defer cfg.Mu.Unlock()
+
+	for normalizedURL, data := range cfg.Pages {
+		if len(data.Redirects) == 0 {
+			continue
+		}
+
+		chain := RedirectChain{
+			URL:      data.Redirects[0].URL,
+			FinalURL: data.FinalURL,
+			Hops:     data.Redirects,
+		}
+		if chain.FinalURL == "" {
+			chain.FinalURL = data.URL
+		}
+
+		if chain.isLoop() {
+			chain.FinalURL = ""
+			audit.Loops = append(audit.Loops, chain)
+		} else if len(chain.Hops) > chainLimit {
+			audit.LongChains = append(audit.LongChains, chain)
+		}
+		if chain.isTemporary() {
+			audit.Temporary = append(audit.Temporary, chain)
+		}
+		if inlink, ok := cfg.Inlinks[normalizedURL]; ok {
+			chain.Referrers = inlink.Referrers
+			audit.LinkedRedirects = append(audit.LinkedRedirects, chain)
+		}
+	}
+
+	for _, chains := range [][]RedirectChain{audit.LongChains, audit.Loops, audit.Temporary, audit.LinkedRedirects} {
+		sort.Slice(chains, func(i, j int) bool {
+			return chains[i].URL < chains[j].URL
+		})
+	}
+	return audit
+}
+
+func formatRedirectHops(hops []RedirectHop) string {
+	parts := make([]string, 0, len(hops)+1)
+	for _, hop := range hops {
+		parts = append(parts, fmt.Sprintf("%s (%d)", hop.URL, hop.StatusCode))
+	}
+	parts = append(parts, hops[len(hops)-1].Location)
+	return strings.Join(parts, " -> ")
+}
+
+func (audit *RedirectAudit) writeText(w io.Writer) {
+	writeSectionHeader(w, "REDIRECTS")
+
+	fmt.Fprintf(w, "Redirect chains longer than %d hops: %d\n", audit.ChainLimit, len(audit.LongChains))
+	for _, chain := range audit.LongChains {
+		fmt.Fprintf(w, "  - %s\n", formatRedirectHops(chain.Hops))
+	}
+
+	fmt.Fprintf(w, "\nRedirect loops: %d\n", len(audit.Loops))
+	for _, chain := range audit.Loops {
+		fmt.Fprintf(w, "  - %s\n", formatRedirectHops(chain.Hops))
+	}
+
+	fmt.Fprintf(w, "\nTemporary (302/307) redirects that may need to be permanent: %d\n", len(audit.Temporary))
+	for _, chain := range audit.Temporary {
+		fmt.Fprintf(w, "  - %s\n", formatRedirectHops(chain.Hops))
+	}
+
+	fmt.Fprintf(w, "\nInternal links pointing at redirects: %d\n", len(audit.LinkedRedirects))
+	for _, chain := range audit.LinkedRedirects {
+		target := chain.FinalURL
+		if target == "" {
+			target = "redirect loop"
+		}
+		fmt.Fprintf(w, "  - %s -> %s (linked from %s)\n", chain.URL, target, strings.Join(chain.Referrers, ", "))
+	}
+}
@@ -0,0 +1,55 @@
+package crawler
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAuditRedirects(t *testing.T) {
+	permanent := []RedirectHop{
+		{URL: "https://blog.boot.dev/old", StatusCode: 301, Location: "https://blog.boot.dev/new"},
+	}
+	chain := []RedirectHop{
+		{URL: "https://blog.boot.dev/a", StatusCode: 301, Location: "https://blog.boot.dev/b"},
+		{URL: "https://blog.boot.dev/b", StatusCode: 302, Location: "https://blog.boot.dev/c"},
+	}
+	loop := []RedirectHop{
+		{URL: "https://blog.boot.dev/x", StatusCode: 301, Location: "https://blog.boot.dev/y"},
+		{URL: "https://blog.boot.dev/y", StatusCode: 301, Location: "https://blog.boot.dev/x"},
+	}
+
+	cfg := &Config{
+		Mu: &sync.Mutex{},
+		Pages: map[string]*PageData{
+			"blog.boot.dev/old": {URL: "https://blog.boot.dev/old", StatusCode: 301, FinalURL: "https://blog.boot.dev/new", Redirects: permanent},
+			"blog.boot.dev/a":   {URL: "https://blog.boot.dev/a", StatusCode: 301, FinalURL: "https://blog.boot.dev/c", Redirects: chain},
+			"blog.boot.dev/x":   {URL: "https://blog.boot.dev/x", StatusCode: 301, FinalURL: "https://blog.boot.dev/x", Redirects: loop},
+			"blog.boot.dev/new": {URL: "https://blog.boot.dev/new", StatusCode: 200},
+		},
+		Inlinks: map[string]*Inlink{
+			"blog.boot.dev/old": {Count: 1, Referrers: []string{"https://blog.boot.dev/"}},
+		},
+	}
+
+	expected := &RedirectAudit{
+		ChainLimit: 1,
+		LongChains: []RedirectChain{
+			{URL: "https://blog.boot.dev/a", FinalURL: "https://blog.boot.dev/c", Hops: chain},
+		},
+		Loops: []RedirectChain{
+			{URL: "https://blog.boot.dev/x", Hops: loop},
+		},
+		Temporary: []RedirectChain{
+			{URL: "https://blog.boot.dev/a", FinalURL: "https://blog.boot.dev/c", Hops: chain},
+		},
+		LinkedRedirects: []RedirectChain{
+			{URL: "https://blog.boot.dev/old", FinalURL: "https://blog.boot.dev/new", Hops: permanent, Referrers: []string{"https://blog.boot.dev/"}},
+		},
+	}
+
+	actual := cfg.AuditRedirects(1)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FAIL: expected audit %+v, actual: %+v", expected, actual)
+	}
+}
@@ -10,19 +10,29 @@ import (
 
 type RobotsChecker struct {
 	disallowed []string
+	sitemaps   []string
 	mu         sync.Mutex
 	userAgent  string
+	fetched    sync.Once
 }
 
-func NewRobotsChecker(baseURL *url.URL, userAgent string) *RobotsChecker {
+func NewRobotsChecker(baseURL *url.URL, userAgent string, client *http.Client) *RobotsChecker {
 	rc := &RobotsChecker{
 		userAgent: userAgent,
 	}
-	rc.fetchRobotsTxt(baseURL)
+	rc.load(baseURL, client)
 	return rc
 }
 
-func (rc *RobotsChecker) fetchRobotsTxt(baseURL *url.URL) {
+// load fetches robots.txt the first time it is called; later and concurrent
+// calls wait for that fetch instead of repeating it.
+func (rc *RobotsChecker) load(baseURL *url.URL, client *http.Client) {
+	rc.fetched.Do(func() {
+		rc.fetchRobotsTxt(baseURL, client)
+	})
+}
+
+func (rc *RobotsChecker) fetchRobotsTxt(baseURL *url.URL, client *http.Client) {
 	robotsURL := baseURL.Scheme + "://" + baseURL.Host + "/robots.txt"
 	req, err := http.NewRequest("GET", robotsURL, nil)
 	if err != nil {
@@ -30,7 +40,7 @@ func (rc *RobotsChecker) fetchRobotsTxt(baseURL *url.URL) {
 	}
 	req.Header.Set("User-Agent", rc.userAgent)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil || resp.StatusCode != 200 {
 		return
 	}
@@ -56,6 +66,15 @@ func (rc *RobotsChecker) parse(body string) {
 		}
 
 		lowerLine := strings.ToLower(line)
+		// Sitemap directives apply regardless of the user-agent group
+		if strings.HasPrefix(lowerLine, "sitemap:") {
+			sitemapURL := strings.TrimSpace(line[8:])
+			if sitemapURL != "" {
+				rc.sitemaps = append(rc.sitemaps, sitemapURL)
+			}
+			continue
+		}
+
 		if strings.HasPrefix(lowerLine, "user-agent:") {
 			agent := strings.TrimSpace(line[11:])
 			if agent == "*" || strings.EqualFold(agent, rc.userAgent) {
@@ -88,3 +107,29 @@ func (rc *RobotsChecker) IsAllowed(u string) bool {
 	}
 	return true
 }
+
+// robotsAllowed checks u against the robots.txt of its own host.
+func (cfg *Config) robotsAllowed(u *url.URL) bool {
+	return cfg.robotsFor(u).IsAllowed(u.String())
+}
+
+// robotsFor returns the robots.txt rules for u's host, fetching them on first
+// use with the configured HTTP client. Workers that miss the cache at the same
+// time share one checker, so each host's robots.txt is fetched once.
+func (cfg *Config) robotsFor(u *url.URL) *RobotsChecker {
+	host := strings.ToLower(u.Host)
+	cfg.Mu.Lock()
+	rc, ok := cfg.RobotsByHost[host]
+	if !ok {
+		rc = &RobotsChecker{userAgent: cfg.UserAgent}
+		cfg.RobotsByHost[host] = rc
+	}
+	cfg.Mu.Unlock()
+
+	rc.load(&url.URL{Scheme: u.Scheme, Host: u.Host}, cfg.HTTPClient)
+	return rc
+}
+
+func (rc *RobotsChecker) Sitemaps() []string {
+	return rc.sitemaps
+}
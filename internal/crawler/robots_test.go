@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobotsForFetchesOncePerHost(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		// slow enough for every worker to miss the cache
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "User-agent: *\nDisallow: /private\n")
+	}))
+	defer server.Close()
+
+	cfg := &Config{
+		Mu:           &sync.Mutex{},
+		RobotsByHost: map[string]*RobotsChecker{},
+		HTTPClient:   NewHTTPClient(DefaultClientOptions()),
+		UserAgent:    "Crawler",
+	}
+	privateURL, _ := url.Parse(server.URL + "/private/page")
+
+	var wg sync.WaitGroup
+	allowed := make([]bool, 10)
+	for i := range allowed {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			allowed[i] = cfg.robotsAllowed(privateURL)
+		}(i)
+	}
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected robots.txt to be fetched once, actual: %d", n)
+	}
+	for i, ok := range allowed {
+		if ok {
+			t.Errorf("worker %d: expected /private/page to be disallowed", i)
+		}
+	}
+}
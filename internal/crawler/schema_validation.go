@@ -0,0 +1,614 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issue severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// SchemaIssue is one problem found in a structured data entity. Property is a
+// dotted path for nested values, e.g. offers.price.
+type SchemaIssue struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Property string `json:"property,omitempty"`
+	Message  string `json:"message"`
+}
+
+type valueKind int
+
+const (
+	kindText valueKind = iota
+	kindNumber
+	kindURL
+	kindDate
+	kindDuration
+	kindImage // a URL or an ImageObject
+	kindThing // a nested object of one of the rule's types
+)
+
+type propertyNeed int
+
+const (
+	needOptional propertyNeed = iota
+	needRecommended
+	needRequired
+)
+
+type propertyRule struct {
+	name   string
+	need   propertyNeed
+	kind   valueKind
+	types  []string // accepted @types for kindThing
+	textOK bool     // kindThing also accepts plain text
+}
+
+type typeRule struct {
+	properties []propertyRule
+	anyOf      []string // at least one of these must be present
+	check      func(properties map[string]any, path string) []SchemaIssue
+}
+
+func schemaProp(name string, need propertyNeed, kind valueKind) propertyRule {
+	return propertyRule{name: name, need: need, kind: kind}
+}
+
+func schemaThing(name string, need propertyNeed, textOK bool, types ...string) propertyRule {
+	return propertyRule{name: name, need: need, kind: kindThing, types: types, textOK: textOK}
+}
+
+// schemaRules is the offline rule set, based on schema.org and the
+// properties search engines need for rich results. Nested types are checked
+// wherever they appear.
+var schemaRules = map[string]typeRule{
+	"Product": {
+		properties: []propertyRule{
+			schemaProp("name", needRequired, kindText),
+			schemaProp("image", needRecommended, kindImage),
+			schemaProp("description", needRecommended, kindText),
+			schemaProp("sku", needRecommended, kindText),
+			schemaThing("brand", needRecommended, true, "Brand", "Organization"),
+			schemaThing("offers", needOptional, false, "Offer", "AggregateOffer"),
+			schemaThing("review", needOptional, false, "Review"),
+			schemaThing("aggregateRating", needOptional, false, "AggregateRating"),
+		},
+		anyOf: []string{"offers", "review", "aggregateRating"},
+	},
+	"Offer": {
+		properties: []propertyRule{
+			schemaProp("price", needRequired, kindNumber),
+			schemaProp("priceCurrency", needRequired, kindText),
+			schemaProp("availability", needRecommended, kindText),
+			schemaProp("priceValidUntil", needOptional, kindDate),
+			schemaProp("url", needOptional, kindURL),
+		},
+		check: checkCurrency,
+	},
+	"AggregateOffer": {
+		properties: []propertyRule{
+			schemaProp("lowPrice", needRequired, kindNumber),
+			schemaProp("priceCurrency", needRequired, kindText),
+			schemaProp("highPrice", needRecommended, kindNumber),
+			schemaProp("offerCount", needRecommended, kindNumber),
+		},
+		check: checkCurrency,
+	},
+	"AggregateRating": {
+		properties: []propertyRule{
+			schemaProp("ratingValue", needRequired, kindNumber),
+			schemaProp("ratingCount", needOptional, kindNumber),
+			schemaProp("reviewCount", needOptional, kindNumber),
+			schemaProp("bestRating", needOptional, kindNumber),
+			schemaProp("worstRating", needOptional, kindNumber),
+		},
+		anyOf: []string{"ratingCount", "reviewCount"},
+	},
+	"Review": {
+		properties: []propertyRule{
+			schemaThing("author", needRequired, true, "Person", "Organization"),
+			schemaThing("reviewRating", needRecommended, false, "Rating"),
+			schemaProp("datePublished", needOptional, kindDate),
+		},
+	},
+	"Rating": {
+		properties: []propertyRule{
+			schemaProp("ratingValue", needRequired, kindNumber),
+			schemaProp("bestRating", needOptional, kindNumber),
+			schemaProp("worstRating", needOptional, kindNumber),
+		},
+	},
+	"Article": {
+		properties: []propertyRule{
+			schemaProp("headline", needRecommended, kindText),
+			schemaProp("image", needRecommended, kindImage),
+			schemaProp("datePublished", needRecommended, kindDate),
+			schemaProp("dateModified", needRecommended, kindDate),
+			schemaThing("author", needRecommended, true, "Person", "Organization"),
+			schemaThing("publisher", needOptional, false, "Organization", "Person"),
+		},
+	},
+	"Person": {
+		properties: []propertyRule{
+			schemaProp("name", needRequired, kindText),
+			schemaProp("url", needOptional, kindURL),
+		},
+	},
+	"BreadcrumbList": {
+		properties: []propertyRule{
+			schemaThing("itemListElement", needRequired, false, "ListItem"),
+		},
+		check: checkBreadcrumbs,
+	},
+	"ListItem": {
+		properties: []propertyRule{
+			schemaProp("position", needRequired, kindNumber),
+			schemaProp("name", needOptional, kindText),
+		},
+	},
+	"FAQPage": {
+		properties: []propertyRule{
+			schemaThing("mainEntity", needRequired, false, "Question"),
+		},
+	},
+	"Question": {
+		properties: []propertyRule{
+			schemaProp("name", needRequired, kindText),
+			schemaThing("acceptedAnswer", needRequired, false, "Answer"),
+		},
+	},
+	"Answer": {
+		properties: []propertyRule{
+			schemaProp("text", needRequired, kindText),
+		},
+	},
+	"Organization": {
+		properties: []propertyRule{
+			schemaProp("name", needRecommended, kindText),
+			schemaProp("url", needRecommended, kindURL),
+			schemaProp("logo", needRecommended, kindImage),
+			schemaProp("sameAs", needOptional, kindURL),
+		},
+	},
+	"Event": {
+		properties: []propertyRule{
+			schemaProp("name", needRequired, kindText),
+			schemaProp("startDate", needRequired, kindDate),
+			schemaThing("location", needRequired, false, "Place", "VirtualLocation", "PostalAddress"),
+			schemaProp("endDate", needRecommended, kindDate),
+			schemaProp("description", needRecommended, kindText),
+			schemaProp("image", needRecommended, kindImage),
+			schemaProp("eventStatus", needRecommended, kindText),
+			schemaProp("eventAttendanceMode", needOptional, kindText),
+			schemaThing("offers", needRecommended, false, "Offer", "AggregateOffer"),
+			schemaThing("organizer", needRecommended, true, "Person", "Organization"),
+			schemaThing("performer", needRecommended, true, "Person", "PerformingGroup", "Organization"),
+		},
+		check: checkEventDates,
+	},
+	"Place": {
+		properties: []propertyRule{
+			schemaProp("name", needRecommended, kindText),
+			schemaThing("address", needRequired, true, "PostalAddress"),
+		},
+	},
+	"VirtualLocation": {
+		properties: []propertyRule{
+			schemaProp("url", needRequired, kindURL),
+		},
+	},
+	"Recipe": {
+		properties: []propertyRule{
+			schemaProp("name", needRequired, kindText),
+			schemaProp("image", needRequired, kindImage),
+			schemaThing("author", needRecommended, true, "Person", "Organization"),
+			schemaProp("datePublished", needRecommended, kindDate),
+			schemaProp("description", needRecommended, kindText),
+			schemaProp("prepTime", needRecommended, kindDuration),
+			schemaProp("cookTime", needRecommended, kindDuration),
+			schemaProp("totalTime", needRecommended, kindDuration),
+			schemaProp("recipeIngredient", needRecommended, kindText),
+			schemaThing("recipeInstructions", needRecommended, true, "HowToStep", "HowToSection"),
+			schemaProp("recipeYield", needRecommended, kindText),
+			schemaThing("aggregateRating", needOptional, false, "AggregateRating"),
+			schemaThing("nutrition", needOptional, false, "NutritionInformation"),
+		},
+	},
+}
+
+// schemaSubtypes are validated with their parent type's rules.
+var schemaSubtypes = map[string]string{
+	"NewsArticle":             "Article",
+	"BlogPosting":             "Article",
+	"TechArticle":             "Article",
+	"ScholarlyArticle":        "Article",
+	"Report":                  "Article",
+	"Corporation":             "Organization",
+	"NGO":                     "Organization",
+	"EducationalOrganization": "Organization",
+	"OnlineStore":             "Organization",
+	"BusinessEvent":           "Event",
+	"ComedyEvent":             "Event",
+	"EducationEvent":          "Event",
+	"ExhibitionEvent":         "Event",
+	"Festival":                "Event",
+	"FoodEvent":               "Event",
+	"MusicEvent":              "Event",
+	"SocialEvent":             "Event",
+	"SportsEvent":             "Event",
+	"TheaterEvent":            "Event",
+}
+
+func canonicalSchemaType(schemaType string) string {
+	if parent, ok := schemaSubtypes[schemaType]; ok {
+		return parent
+	}
+	return schemaType
+}
+
+// ValidateEntity checks an entity against the rules for each of its types;
+// types without rules aren't checked.
+func ValidateEntity(entity StructuredEntity) []SchemaIssue {
+	var issues []SchemaIssue
+	for _, schemaType := range entity.Types {
+		for _, issue := range validateNode(schemaType, entity.Properties, "") {
+			issue.Type = schemaType
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func validateNode(schemaType string, properties map[string]any, path string) []SchemaIssue {
+	rule, ok := schemaRules[canonicalSchemaType(schemaType)]
+	if !ok {
+		return nil
+	}
+
+	var issues []SchemaIssue
+	for _, p := range rule.properties {
+		value, present := properties[p.name]
+		if !present || isEmptyValue(value) {
+			switch p.need {
+			case needRequired:
+				issues = append(issues, SchemaIssue{Severity: SeverityError, Property: path + p.name, Message: "missing required property"})
+			case needRecommended:
+				issues = append(issues, SchemaIssue{Severity: SeverityWarning, Property: path + p.name, Message: "missing recommended property"})
+			}
+			continue
+		}
+		for _, item := range valueList(value) {
+			issues = append(issues, checkValue(p, item, path+p.name)...)
+		}
+	}
+
+	if len(rule.anyOf) > 0 {
+		found := false
+		for _, name := range rule.anyOf {
+			if value, ok := properties[name]; ok && !isEmptyValue(value) {
+				found = true
+			}
+		}
+		if !found {
+			issues = append(issues, SchemaIssue{
+				Severity: SeverityError,
+				Property: path + strings.Join(rule.anyOf, "|"),
+				Message:  "needs at least one of " + strings.Join(rule.anyOf, ", "),
+			})
+		}
+	}
+	if rule.check != nil {
+		issues = append(issues, rule.check(properties, path)...)
+	}
+	return issues
+}
+
+func checkValue(p propertyRule, value any, path string) []SchemaIssue {
+	invalid := func(format string, args ...any) []SchemaIssue {
+		return []SchemaIssue{{Severity: SeverityError, Property: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if p.kind == kindThing {
+		node, isObject := value.(map[string]any)
+		if !isObject {
+			if p.textOK {
+				return nil
+			}
+			return invalid("should be %s, got %s", describeTypes(p.types), describeValue(value))
+		}
+		nodeType := firstType(node)
+		if nodeType == "" {
+			issues := []SchemaIssue{{Severity: SeverityWarning, Property: path, Message: fmt.Sprintf("has no @type, expected %s", describeTypes(p.types))}}
+			return append(issues, validateNode(p.types[0], node, path+".")...)
+		}
+		if !acceptsType(p.types, nodeType) {
+			return invalid("should be %s, got %s", describeTypes(p.types), nodeType)
+		}
+		return validateNode(nodeType, node, path+".")
+	}
+
+	if node, isObject := value.(map[string]any); isObject && p.kind == kindImage {
+		imageURL, _ := node["url"].(string)
+		if imageURL == "" {
+			imageURL, _ = node["contentUrl"].(string)
+		}
+		if imageURL == "" {
+			return invalid("%s has no url or contentUrl", describeValue(value))
+		}
+		value = imageURL
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = strings.TrimSpace(v)
+	case json.Number:
+		text = v.String()
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		if p.kind == kindText && isScalar(value) {
+			return nil
+		}
+		return invalid("should be %s, got %s", describeKind(p.kind), describeValue(value))
+	}
+
+	switch p.kind {
+	case kindNumber:
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64); err != nil {
+			return invalid("should be a number, got %q", text)
+		}
+	case kindURL, kindImage:
+		if !isValidSchemaURL(text) {
+			return invalid("invalid URL %q", text)
+		}
+	case kindDate:
+		if !isISODate(text) {
+			return invalid("invalid ISO 8601 date %q", text)
+		}
+	case kindDuration:
+		if !isISODuration(text) {
+			return invalid("invalid ISO 8601 duration %q", text)
+		}
+	}
+	return nil
+}
+
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case string, json.Number, float64, bool:
+		return true
+	}
+	return false
+}
+
+func valueList(value any) []any {
+	if list, ok := value.([]any); ok {
+		return list
+	}
+	return []any{value}
+}
+
+func firstType(node map[string]any) string {
+	for _, t := range valueList(node["@type"]) {
+		if s, ok := t.(string); ok && s != "" {
+			return normalizeSchemaTerm(s)
+		}
+	}
+	return ""
+}
+
+func acceptsType(types []string, nodeType string) bool {
+	for _, t := range types {
+		if t == nodeType || t == canonicalSchemaType(nodeType) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTypes(types []string) string {
+	return strings.Join(types, " or ")
+}
+
+func describeKind(kind valueKind) string {
+	switch kind {
+	case kindNumber:
+		return "a number"
+	case kindURL:
+		return "a URL"
+	case kindDate:
+		return "a date"
+	case kindDuration:
+		return "a duration"
+	case kindImage:
+		return "an image URL or ImageObject"
+	}
+	return "text"
+}
+
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case map[string]any:
+		if t := firstType(v); t != "" {
+			return t
+		}
+		return "an object"
+	case []any:
+		return "a list"
+	case bool:
+		return "a boolean"
+	case string:
+		return "text"
+	}
+	return "a number"
+}
+
+// isValidSchemaURL accepts absolute http(s) URLs and relative references,
+// which JSON-LD and Microdata resolve against the page.
+func isValidSchemaURL(rawURL string) bool {
+	if rawURL == "" || strings.ContainsAny(rawURL, " \t\n") {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return true
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+var (
+	isoDatePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?)?$`)
+	isoDurationPattern = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+)
+
+// isISODate accepts a calendar date optionally followed by a time and zone.
+func isISODate(value string) bool {
+	if !isoDatePattern.MatchString(value) {
+		return false
+	}
+	if _, err := time.Parse("2006-01-02", value[:10]); err != nil {
+		return false
+	}
+	if len(value) > 10 {
+		if _, err := time.Parse("15:04", value[11:16]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isISODuration(value string) bool {
+	return isoDurationPattern.MatchString(value) && value != "P" && !strings.HasSuffix(value, "T")
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func checkCurrency(properties map[string]any, path string) []SchemaIssue {
+	currency, ok := properties["priceCurrency"].(string)
+	if !ok || currency == "" || currencyPattern.MatchString(currency) {
+		return nil
+	}
+	return []SchemaIssue{{Severity: SeverityError, Property: path + "priceCurrency", Message: fmt.Sprintf("should be a 3-letter ISO 4217 code, got %q", currency)}}
+}
+
+// checkBreadcrumbs requires every crumb but the last to link somewhere and
+// the positions to count up from 1.
+func checkBreadcrumbs(properties map[string]any, path string) []SchemaIssue {
+	var issues []SchemaIssue
+	items := valueList(properties["itemListElement"])
+	for i, value := range items {
+		item, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		itemPath := fmt.Sprintf("%sitemListElement[%d]", path, i)
+		if i < len(items)-1 && isEmptyValue(item["item"]) {
+			issues = append(issues, SchemaIssue{Severity: SeverityError, Property: itemPath + ".item", Message: "missing required property"})
+		}
+		if isEmptyValue(item["name"]) {
+			if nested, ok := item["item"].(map[string]any); !ok || isEmptyValue(nested["name"]) {
+				issues = append(issues, SchemaIssue{Severity: SeverityError, Property: itemPath + ".name", Message: "missing required property"})
+			}
+		}
+		if position, err := strconv.Atoi(fmt.Sprint(item["position"])); err == nil && position != i+1 {
+			issues = append(issues, SchemaIssue{Severity: SeverityWarning, Property: itemPath + ".position", Message: fmt.Sprintf("expected position %d, got %d", i+1, position)})
+		}
+	}
+	return issues
+}
+
+func checkEventDates(properties map[string]any, path string) []SchemaIssue {
+	start, _ := properties["startDate"].(string)
+	end, _ := properties["endDate"].(string)
+	if !isISODate(start) || !isISODate(end) {
+		return nil
+	}
+	// comparing the date part is enough to catch swapped dates
+	if end[:10] < start[:10] {
+		return []SchemaIssue{{Severity: SeverityError, Property: path + "endDate", Message: fmt.Sprintf("ends (%s) before it starts (%s)", end, start)}}
+	}
+	return nil
+}
+
+type SchemaValidationPage struct {
+	URL    string        `json:"url"`
+	Issues []SchemaIssue `json:"issues"`
+}
+
+// SchemaValidation lists the structured data problems found on each page.
+type SchemaValidation struct {
+	EntitiesChecked int                    `json:"entities_checked"`
+	Errors          int                    `json:"errors"`
+	Warnings        int                    `json:"warnings"`
+	Pages           []SchemaValidationPage `json:"pages"`
+}
+
+// ValidateStructuredData runs the offline rule set over the structured data
+// of every crawled page.
+func ValidateStructuredData(pages []Page) *SchemaValidation {
+	validation := &SchemaValidation{Pages: []SchemaValidationPage{}}
+	for _, page := range pages {
+		if !isHTMLPage(page.StatusCode, page.FinalURL, page.ContentType) {
+			continue
+		}
+		var issues []SchemaIssue
+		for _, entity := range page.StructuredData {
+			validation.EntitiesChecked++
+			issues = append(issues, ValidateEntity(entity)...)
+		}
+		if len(issues) == 0 {
+			continue
+		}
+		for _, issue := range issues {
+			if issue.Severity == SeverityError {
+				validation.Errors++
+			} else {
+				validation.Warnings++
+			}
+		}
+		validation.Pages = append(validation.Pages, SchemaValidationPage{URL: page.URL, Issues: issues})
+	}
+	sort.Slice(validation.Pages, func(i, j int) bool {
+		return validation.Pages[i].URL < validation.Pages[j].URL
+	})
+	return validation
+}
+
+func (validation *SchemaValidation) writeText(w io.Writer) {
+	writeSectionHeader(w, "STRUCTURED DATA VALIDATION")
+	fmt.Fprintf(w, "%d entities checked: %d errors, %d warnings\n", validation.EntitiesChecked, validation.Errors, validation.Warnings)
+
+	for _, page := range validation.Pages {
+		fmt.Fprintf(w, "\n%s\n", page.URL)
+		for _, issue := range page.Issues {
+			fmt.Fprintf(w, "  - [%s] %s %s: %s\n", issue.Severity, issue.Type, issue.Property, issue.Message)
+		}
+	}
+}
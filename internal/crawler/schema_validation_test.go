@@ -0,0 +1,158 @@
+package crawler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateEntity(t *testing.T) {
+	tests := []struct {
+		name     string
+		entity   StructuredEntity
+		expected []SchemaIssue
+	}{
+		{
+			name: "valid product",
+			entity: StructuredEntity{Types: []string{"Product"}, Properties: map[string]any{
+				"name": "Gopher plush", "image": "https://blog.boot.dev/gopher.png", "description": "Soft", "sku": "G-1", "brand": "Boot.dev",
+				"offers": map[string]any{"@type": "Offer", "price": json.Number("19.99"), "priceCurrency": "USD", "availability": "https://schema.org/InStock"},
+			}},
+			expected: nil,
+		},
+		{
+			name: "product missing offers, reviews and ratings",
+			entity: StructuredEntity{Types: []string{"Product"}, Properties: map[string]any{
+				"name": "Gopher plush", "image": "/gopher.png", "description": "Soft", "sku": "G-1", "brand": "Boot.dev",
+			}},
+			expected: []SchemaIssue{
+				{Type: "Product", Severity: SeverityError, Property: "offers|review|aggregateRating", Message: "needs at least one of offers, review, aggregateRating"},
+			},
+		},
+		{
+			name: "nested offer with wrong types",
+			entity: StructuredEntity{Types: []string{"Product"}, Properties: map[string]any{
+				"name": "Gopher plush", "image": "/gopher.png", "description": "Soft", "sku": "G-1", "brand": "Boot.dev",
+				"offers": map[string]any{"@type": "Offer", "price": "free", "priceCurrency": "dollars", "availability": "InStock", "priceValidUntil": "2025-02-30"},
+			}},
+			expected: []SchemaIssue{
+				{Type: "Product", Severity: SeverityError, Property: "offers.price", Message: `should be a number, got "free"`},
+				{Type: "Product", Severity: SeverityError, Property: "offers.priceValidUntil", Message: `invalid ISO 8601 date "2025-02-30"`},
+				{Type: "Product", Severity: SeverityError, Property: "offers.priceCurrency", Message: `should be a 3-letter ISO 4217 code, got "dollars"`},
+			},
+		},
+		{
+			name: "article subtype with missing recommended properties",
+			entity: StructuredEntity{Types: []string{"BlogPosting"}, Properties: map[string]any{
+				"headline": "Hello", "datePublished": "2025-01-02T10:00:00+02:00", "author": map[string]any{"@type": "Person"},
+			}},
+			expected: []SchemaIssue{
+				{Type: "BlogPosting", Severity: SeverityWarning, Property: "image", Message: "missing recommended property"},
+				{Type: "BlogPosting", Severity: SeverityWarning, Property: "dateModified", Message: "missing recommended property"},
+				{Type: "BlogPosting", Severity: SeverityError, Property: "author.name", Message: "missing required property"},
+			},
+		},
+		{
+			name: "breadcrumbs",
+			entity: StructuredEntity{Types: []string{"BreadcrumbList"}, Properties: map[string]any{
+				"itemListElement": []any{
+					map[string]any{"@type": "ListItem", "position": json.Number("1"), "name": "Home", "item": "https://blog.boot.dev/"},
+					map[string]any{"@type": "ListItem", "position": json.Number("3"), "name": "Go"},
+					map[string]any{"@type": "ListItem", "position": json.Number("3"), "name": "Post"},
+				},
+			}},
+			expected: []SchemaIssue{
+				{Type: "BreadcrumbList", Severity: SeverityError, Property: "itemListElement[1].item", Message: "missing required property"},
+				{Type: "BreadcrumbList", Severity: SeverityWarning, Property: "itemListElement[1].position", Message: "expected position 2, got 3"},
+			},
+		},
+		{
+			name: "FAQ with a question missing its answer",
+			entity: StructuredEntity{Types: []string{"FAQPage"}, Properties: map[string]any{
+				"mainEntity": []any{
+					map[string]any{"@type": "Question", "name": "Why Go?", "acceptedAnswer": map[string]any{"@type": "Answer", "text": "Speed"}},
+					map[string]any{"@type": "Question", "name": "Why not?"},
+					"a plain string",
+				},
+			}},
+			expected: []SchemaIssue{
+				{Type: "FAQPage", Severity: SeverityError, Property: "mainEntity.acceptedAnswer", Message: "missing required property"},
+				{Type: "FAQPage", Severity: SeverityError, Property: "mainEntity", Message: "should be Question, got text"},
+			},
+		},
+		{
+			name: "organization with an invalid URL",
+			entity: StructuredEntity{Types: []string{"Organization"}, Properties: map[string]any{
+				"name": "Boot.dev", "url": "javascript:void(0)", "logo": map[string]any{"@type": "ImageObject", "url": "https://blog.boot.dev/logo.png"},
+				"sameAs": []any{"https://twitter.com/bootdotdev", "not a url"},
+			}},
+			expected: []SchemaIssue{
+				{Type: "Organization", Severity: SeverityError, Property: "url", Message: `invalid URL "javascript:void(0)"`},
+				{Type: "Organization", Severity: SeverityError, Property: "sameAs", Message: `invalid URL "not a url"`},
+			},
+		},
+		{
+			name: "event ending before it starts at a place without an address",
+			entity: StructuredEntity{Types: []string{"MusicEvent"}, Properties: map[string]any{
+				"name": "Launch", "startDate": "2025-06-02", "endDate": "2025-06-01", "description": "Party", "image": "/launch.png",
+				"eventStatus": "https://schema.org/EventScheduled", "offers": map[string]any{"@type": "Offer", "price": "0", "priceCurrency": "USD", "availability": "InStock"},
+				"organizer": "Boot.dev", "performer": "The Gophers",
+				"location": map[string]any{"@type": "Place", "name": "Hall"},
+			}},
+			expected: []SchemaIssue{
+				{Type: "MusicEvent", Severity: SeverityError, Property: "location.address", Message: "missing required property"},
+				{Type: "MusicEvent", Severity: SeverityError, Property: "endDate", Message: "ends (2025-06-01) before it starts (2025-06-02)"},
+			},
+		},
+		{
+			name: "recipe with invalid durations and an image without a URL",
+			entity: StructuredEntity{Types: []string{"Recipe"}, Properties: map[string]any{
+				"name": "Pancakes", "image": []any{"https://blog.boot.dev/1.jpg", map[string]any{"@type": "ImageObject"}},
+				"author": "Lane", "datePublished": "2025-01-02", "description": "Fluffy", "prepTime": "10 minutes", "cookTime": "PT10M", "totalTime": "PT",
+				"recipeIngredient": []any{"flour", "milk"}, "recipeInstructions": "Mix and fry", "recipeYield": json.Number("4"),
+			}},
+			expected: []SchemaIssue{
+				{Type: "Recipe", Severity: SeverityError, Property: "image", Message: "ImageObject has no url or contentUrl"},
+				{Type: "Recipe", Severity: SeverityError, Property: "prepTime", Message: `invalid ISO 8601 duration "10 minutes"`},
+				{Type: "Recipe", Severity: SeverityError, Property: "totalTime", Message: `invalid ISO 8601 duration "PT"`},
+			},
+		},
+		{
+			name:     "types without rules",
+			entity:   StructuredEntity{Types: []string{"WebSite"}, Properties: map[string]any{"name": map[string]any{}}},
+			expected: nil,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := ValidateEntity(tc.entity)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateStructuredData(t *testing.T) {
+	pages := []Page{
+		{URL: "https://blog.boot.dev/b", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{StructuredData: []StructuredEntity{
+			{Types: []string{"Event"}, Properties: map[string]any{"name": "Launch"}},
+		}}},
+		{URL: "https://blog.boot.dev/a", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{StructuredData: []StructuredEntity{
+			{Types: []string{"Organization"}, Properties: map[string]any{"name": "Boot.dev", "url": "https://boot.dev", "logo": "/logo.png"}},
+		}}},
+	}
+
+	validation := ValidateStructuredData(pages)
+	if validation.EntitiesChecked != 2 {
+		t.Errorf("expected 2 entities checked, actual: %d", validation.EntitiesChecked)
+	}
+	// Event: startDate and location required, seven recommended properties
+	if validation.Errors != 2 || validation.Warnings != 7 {
+		t.Errorf("expected 2 errors and 7 warnings, actual: %d errors, %d warnings", validation.Errors, validation.Warnings)
+	}
+	if len(validation.Pages) != 1 || validation.Pages[0].URL != "https://blog.boot.dev/b" {
+		t.Errorf("expected only the event page to have issues, actual: %+v", validation.Pages)
+	}
+}
@@ -0,0 +1,115 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Scope decides which URLs the crawler is allowed to enqueue.
+type Scope struct {
+	AllowedHosts []string // exact hosts, or "*.example.com" for the domain and all its subdomains
+	PathPrefixes []string // when set, the path must start with one of these
+	Include      []*regexp.Regexp
+	Exclude      []*regexp.Regexp
+}
+
+func NewScope(baseURL *url.URL, allowedHosts, pathPrefixes, include, exclude []string) (Scope, error) {
+	scope := Scope{
+		AllowedHosts: allowedHosts,
+		PathPrefixes: pathPrefixes,
+	}
+	if len(scope.AllowedHosts) == 0 {
+		scope.AllowedHosts = []string{baseURL.Hostname()}
+	}
+
+	for _, pattern := range include {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Scope{}, fmt.Errorf("couldn't compile include pattern '%s': %v", pattern, err)
+		}
+		scope.Include = append(scope.Include, re)
+	}
+	for _, pattern := range exclude {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Scope{}, fmt.Errorf("couldn't compile exclude pattern '%s': %v", pattern, err)
+		}
+		scope.Exclude = append(scope.Exclude, re)
+	}
+
+	return scope, nil
+}
+
+func (s Scope) Allows(u *url.URL) bool {
+	if !s.AllowsHost(u.Hostname()) {
+		return false
+	}
+
+	if len(s.PathPrefixes) > 0 {
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		matched := false
+		for _, prefix := range s.PathPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	rawURL := u.String()
+	if len(s.Include) > 0 {
+		matched := false
+		for _, re := range s.Include {
+			if re.MatchString(rawURL) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, re := range s.Exclude {
+		if re.MatchString(rawURL) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s Scope) AllowsHost(host string) bool {
+	for _, allowed := range s.AllowedHosts {
+		if matchesHost(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesHost reports whether host is pattern, or for "*.example.com"
+// patterns, whether it is the domain or one of its subdomains.
+func matchesHost(pattern, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	pattern = strings.ToLower(pattern)
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+func (cfg *Config) inScope(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return cfg.Scope.Allows(u)
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestScopeAllows(t *testing.T) {
+	baseURL, _ := url.Parse("https://blog.boot.dev")
+
+	tests := []struct {
+		name         string
+		allowedHosts []string
+		pathPrefixes []string
+		include      []string
+		exclude      []string
+		inputURL     string
+		expected     bool
+	}{
+		{
+			name:     "base host by default",
+			inputURL: "https://blog.boot.dev/path",
+			expected: true,
+		},
+		{
+			name:     "other host by default",
+			inputURL: "https://boot.dev/path",
+			expected: false,
+		},
+		{
+			name:         "subdomain wildcard",
+			allowedHosts: []string{"*.boot.dev"},
+			inputURL:     "https://docs.boot.dev/path",
+			expected:     true,
+		},
+		{
+			name:         "subdomain wildcard matches apex",
+			allowedHosts: []string{"*.boot.dev"},
+			inputURL:     "https://BOOT.dev/path",
+			expected:     true,
+		},
+		{
+			name:         "subdomain wildcard rejects lookalike",
+			allowedHosts: []string{"*.boot.dev"},
+			inputURL:     "https://notboot.dev/path",
+			expected:     false,
+		},
+		{
+			name:         "multiple hosts",
+			allowedHosts: []string{"boot.dev", "www.boot.dev"},
+			inputURL:     "https://www.boot.dev/",
+			expected:     true,
+		},
+		{
+			name:         "path prefix",
+			pathPrefixes: []string{"/blog/"},
+			inputURL:     "https://blog.boot.dev/blog/post",
+			expected:     true,
+		},
+		{
+			name:         "outside path prefix",
+			pathPrefixes: []string{"/blog/"},
+			inputURL:     "https://blog.boot.dev/about",
+			expected:     false,
+		},
+		{
+			name:     "include pattern",
+			include:  []string{`/20\d\d/`},
+			inputURL: "https://blog.boot.dev/2024/post",
+			expected: true,
+		},
+		{
+			name:     "include pattern not matched",
+			include:  []string{`/20\d\d/`},
+			inputURL: "https://blog.boot.dev/tags/go",
+			expected: false,
+		},
+		{
+			name:     "exclude pattern",
+			exclude:  []string{`\?page=`},
+			inputURL: "https://blog.boot.dev/posts?page=2",
+			expected: false,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scope, err := NewScope(baseURL, tc.allowedHosts, tc.pathPrefixes, tc.include, tc.exclude)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			}
+			inputURL, err := url.Parse(tc.inputURL)
+			if err != nil {
+				t.Errorf("Test %v - '%s' FAIL: couldn't parse input URL: %v", i, tc.name, err)
+				return
+			}
+
+			if actual := scope.Allows(inputURL); actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SeverityNotice marks on-page findings that are worth a look but rarely
+// hurt on their own.
+const SeverityNotice = "notice"
+
+// SEORuleOptions holds the thresholds used by the on-page rules and the IDs
+// of rules that shouldn't run.
+type SEORuleOptions struct {
+	MinTitleLength       int
+	MaxTitleLength       int
+	MinDescriptionLength int
+	MaxDescriptionLength int
+	MinWordCount         int
+	Disabled             []string
+}
+
+func DefaultSEORuleOptions() SEORuleOptions {
+	return SEORuleOptions{
+		MinTitleLength:       30,
+		MaxTitleLength:       60,
+		MinDescriptionLength: 70,
+		MaxDescriptionLength: 160,
+		MinWordCount:         300,
+	}
+}
+
+// seoRule is one deterministic on-page check. Check returns a message for
+// each problem found on the page.
+type seoRule struct {
+	ID       string
+	Severity string
+	Check    func(page *Page, site *seoSite) []string
+}
+
+// seoSite carries what the rules need to know about the rest of the crawl.
+type seoSite struct {
+	opts         SEORuleOptions
+	titles       map[string]int // normalized title -> pages using it
+	descriptions map[string]int // normalized <meta name="description"> -> pages using it
+}
+
+var seoRules = []seoRule{
+	{ID: "title-missing", Severity: SeverityError, Check: func(page *Page, site *seoSite) []string {
+		if strings.TrimSpace(page.Title) == "" {
+			return []string{"missing <title>"}
+		}
+		return nil
+	}},
+	{ID: "title-too-short", Severity: SeverityNotice, Check: func(page *Page, site *seoSite) []string {
+		return tooShort("title", page.Title, site.opts.MinTitleLength)
+	}},
+	{ID: "title-too-long", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		return tooLong("title", page.Title, site.opts.MaxTitleLength)
+	}},
+	{ID: "title-duplicate", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		return duplicated("title", page.Title, site.titles)
+	}},
+	{ID: "description-missing", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		if strings.TrimSpace(page.MetaDescription) == "" {
+			return []string{"missing meta description"}
+		}
+		return nil
+	}},
+	{ID: "description-too-short", Severity: SeverityNotice, Check: func(page *Page, site *seoSite) []string {
+		return tooShort("meta description", page.MetaDescription, site.opts.MinDescriptionLength)
+	}},
+	{ID: "description-too-long", Severity: SeverityNotice, Check: func(page *Page, site *seoSite) []string {
+		return tooLong("meta description", page.MetaDescription, site.opts.MaxDescriptionLength)
+	}},
+	{ID: "description-duplicate", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		return duplicated("meta description", page.MetaDescription, site.descriptions)
+	}},
+	{ID: "h1-missing", Severity: SeverityError, Check: func(page *Page, site *seoSite) []string {
+		if len(page.H1s()) == 0 {
+			return []string{"missing <h1>"}
+		}
+		return nil
+	}},
+	{ID: "h1-multiple", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		if h1s := page.H1s(); len(h1s) > 1 {
+			return []string{fmt.Sprintf("page has %d <h1> headings", len(h1s))}
+		}
+		return nil
+	}},
+	{ID: "h1-empty", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		if slices.Contains(page.H1s(), "") {
+			return []string{"<h1> is empty"}
+		}
+		return nil
+	}},
+	{ID: "heading-skip", Severity: SeverityNotice, Check: func(page *Page, site *seoSite) []string {
+		var messages []string
+		previous := 0
+		for _, heading := range page.Headings {
+			if previous > 0 && heading.Level > previous+1 {
+				messages = append(messages, fmt.Sprintf("<h%d> %q follows an <h%d>", heading.Level, heading.Text, previous))
+			}
+			previous = heading.Level
+		}
+		return messages
+	}},
+	{ID: "img-alt-missing", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		if len(page.ImagesWithoutAlt) > 0 {
+			return []string{fmt.Sprintf("%d of %d images are missing alt text: %s", len(page.ImagesWithoutAlt), page.ImageCount, strings.Join(page.ImagesWithoutAlt, ", "))}
+		}
+		return nil
+	}},
+	{ID: "content-thin", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		if page.WordCount < site.opts.MinWordCount {
+			return []string{fmt.Sprintf("page has %d words, minimum is %d", page.WordCount, site.opts.MinWordCount)}
+		}
+		return nil
+	}},
+	{ID: "lang-missing", Severity: SeverityWarning, Check: func(page *Page, site *seoSite) []string {
+		if strings.TrimSpace(page.Language) == "" {
+			return []string{"missing <html lang>"}
+		}
+		return nil
+	}},
+	{ID: "canonical-missing", Severity: SeverityNotice, Check: func(page *Page, site *seoSite) []string {
+		if page.Canonical == "" && page.HeaderCanonical == "" {
+			return []string{"missing canonical <link> or Link header"}
+		}
+		return nil
+	}},
+	{ID: "charset-missing", Severity: SeverityNotice, Check: func(page *Page, site *seoSite) []string {
+		if page.Charset == "" && page.HeaderCharset == "" {
+			return []string{"missing charset in <meta> or Content-Type"}
+		}
+		return nil
+	}},
+}
+
+// SEORuleIDs lists the built-in rules in the order they run.
+func SEORuleIDs() []string {
+	ids := make([]string, len(seoRules))
+	for i, rule := range seoRules {
+		ids[i] = rule.ID
+	}
+	return ids
+}
+
+// ValidateSEORuleIDs returns an error naming any ID that isn't a built-in rule.
+func ValidateSEORuleIDs(ids []string) error {
+	known := SEORuleIDs()
+	for _, id := range ids {
+		if !slices.Contains(known, id) {
+			return fmt.Errorf("unknown rule %q, expected one of %s", id, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+func tooShort(field, value string, minLength int) []string {
+	value = strings.TrimSpace(value)
+	if length := utf8.RuneCountInString(value); value != "" && length < minLength {
+		return []string{fmt.Sprintf("%s is %d characters, minimum is %d", field, length, minLength)}
+	}
+	return nil
+}
+
+func tooLong(field, value string, maxLength int) []string {
+	if length := utf8.RuneCountInString(strings.TrimSpace(value)); maxLength > 0 && length > maxLength {
+		return []string{fmt.Sprintf("%s is %d characters, maximum is %d", field, length, maxLength)}
+	}
+	return nil
+}
+
+func duplicated(field, value string, counts map[string]int) []string {
+	if key := normalizeText(value); key != "" && counts[key] > 1 {
+		return []string{fmt.Sprintf("%s is shared by %d pages", field, counts[key])}
+	}
+	return nil
+}
+
+// normalizeText folds case and whitespace so near-identical text compares equal.
+func normalizeText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+type SEOIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type SEOPageResult struct {
+	URL    string     `json:"url"`
+	Issues []SEOIssue `json:"issues"`
+}
+
+type SEORuleSummary struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Pages    int    `json:"pages"`
+}
+
+// SEOAudit is the result of running the on-page rules over every page.
+type SEOAudit struct {
+	PagesChecked int              `json:"pages_checked"`
+	Disabled     []string         `json:"disabled,omitempty"`
+	Errors       int              `json:"errors"`
+	Warnings     int              `json:"warnings"`
+	Notices      int              `json:"notices"`
+	Rules        []SEORuleSummary `json:"rules"`
+	Pages        []SEOPageResult  `json:"pages"`
+}
+
+// AuditSEO runs the enabled on-page rules over the crawled HTML pages.
+func AuditSEO(pages []Page, opts SEORuleOptions) *SEOAudit {
+	audit := &SEOAudit{
+		Disabled: opts.Disabled,
+		Rules:    []SEORuleSummary{},
+		Pages:    []SEOPageResult{},
+	}
+	site := &seoSite{opts: opts, titles: map[string]int{}, descriptions: map[string]int{}}
+
+	var checked []*Page
+	for i := range pages {
+		page := &pages[i]
+		if !isHTMLPage(page.StatusCode, page.FinalURL, page.ContentType) {
+			continue
+		}
+		checked = append(checked, page)
+		if key := normalizeText(page.Title); key != "" {
+			site.titles[key]++
+		}
+		if key := normalizeText(page.MetaDescription); key != "" {
+			site.descriptions[key]++
+		}
+	}
+	audit.PagesChecked = len(checked)
+
+	pagesByRule := map[string]int{}
+	for _, page := range checked {
+		result := SEOPageResult{URL: page.URL}
+		for _, rule := range seoRules {
+			if slices.Contains(opts.Disabled, rule.ID) {
+				continue
+			}
+			messages := rule.Check(page, site)
+			if len(messages) > 0 {
+				pagesByRule[rule.ID]++
+			}
+			for _, message := range messages {
+				result.Issues = append(result.Issues, SEOIssue{Rule: rule.ID, Severity: rule.Severity, Message: message})
+				switch rule.Severity {
+				case SeverityError:
+					audit.Errors++
+				case SeverityWarning:
+					audit.Warnings++
+				default:
+					audit.Notices++
+				}
+			}
+		}
+		if len(result.Issues) > 0 {
+			audit.Pages = append(audit.Pages, result)
+		}
+	}
+
+	for _, rule := range seoRules {
+		if pagesByRule[rule.ID] > 0 {
+			audit.Rules = append(audit.Rules, SEORuleSummary{Rule: rule.ID, Severity: rule.Severity, Pages: pagesByRule[rule.ID]})
+		}
+	}
+	sort.SliceStable(audit.Rules, func(i, j int) bool {
+		return audit.Rules[i].Pages > audit.Rules[j].Pages
+	})
+	sort.Slice(audit.Pages, func(i, j int) bool {
+		return audit.Pages[i].URL < audit.Pages[j].URL
+	})
+	return audit
+}
+
+func (audit *SEOAudit) writeText(w io.Writer) {
+	writeSectionHeader(w, "ON-PAGE SEO")
+	fmt.Fprintf(w, "%d pages checked: %d errors, %d warnings, %d notices\n", audit.PagesChecked, audit.Errors, audit.Warnings, audit.Notices)
+	if len(audit.Disabled) > 0 {
+		fmt.Fprintf(w, "Disabled rules: %s\n", strings.Join(audit.Disabled, ", "))
+	}
+
+	fmt.Fprintf(w, "\nRules triggered: %d\n", len(audit.Rules))
+	for _, rule := range audit.Rules {
+		fmt.Fprintf(w, "  - %s (%s): %d pages\n", rule.Rule, rule.Severity, rule.Pages)
+	}
+
+	for _, page := range audit.Pages {
+		fmt.Fprintf(w, "\n%s\n", page.URL)
+		for _, issue := range page.Issues {
+			fmt.Fprintf(w, "  - [%s] %s: %s\n", issue.Severity, issue.Rule, issue.Message)
+		}
+	}
+}
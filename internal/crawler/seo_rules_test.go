@@ -0,0 +1,184 @@
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractContent(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head><title>Ignored words</title><style>p { color: red }</style></head>
+		<body><h1>Learn <em>Go</em></h1><p>One two three.</p>
+		<script>var notCounted = 1;</script>
+		<h3>Deep</h3><img src="/a.png" alt=""><img src="/b.png"></body></html>`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var meta PageMetadata
+	extractContent(doc, &meta)
+
+	expectedHeadings := []Heading{{Level: 1, Text: "Learn Go"}, {Level: 3, Text: "Deep"}}
+	if !reflect.DeepEqual(meta.Headings, expectedHeadings) {
+		t.Errorf("expected headings: %+v, actual: %+v", expectedHeadings, meta.Headings)
+	}
+	if meta.ImageCount != 2 || !reflect.DeepEqual(meta.ImagesWithoutAlt, []string{"/b.png"}) {
+		t.Errorf("expected 2 images with /b.png missing alt, actual: %d, %v", meta.ImageCount, meta.ImagesWithoutAlt)
+	}
+	if meta.WordCount != 6 {
+		t.Errorf("expected 6 words, actual: %d", meta.WordCount)
+	}
+}
+
+func TestAuditSEO(t *testing.T) {
+	good := PageMetadata{
+		Title:           "A perfectly sized title for the gopher page",
+		MetaDescription: "A meta description that is long enough to pass the minimum length check easily.",
+		Canonical:       "/good",
+		Language:        "en",
+		Charset:         "utf-8",
+		Headings:        []Heading{{Level: 1, Text: "Gophers"}, {Level: 2, Text: "Habitat"}},
+		ImageCount:      1,
+		WordCount:       500,
+	}
+	bad := PageMetadata{
+		Title:            "Short",
+		Description:      "Taken from og:description",
+		Headings:         []Heading{{Level: 1, Text: "One"}, {Level: 1, Text: ""}, {Level: 4, Text: "Deep"}},
+		ImageCount:       2,
+		ImagesWithoutAlt: []string{"/a.png"},
+		WordCount:        12,
+	}
+	duplicate := good
+	duplicate.Title = "  A PERFECTLY sized title for the   gopher page "
+
+	pages := []Page{
+		{URL: "https://blog.boot.dev/good", StatusCode: 200, ContentType: "text/html", PageMetadata: good},
+		{URL: "https://blog.boot.dev/bad", StatusCode: 200, ContentType: "text/html", HeaderCharset: "utf-8", PageMetadata: bad},
+		{URL: "https://blog.boot.dev/dup", StatusCode: 200, ContentType: "text/html", PageMetadata: duplicate},
+		{URL: "https://blog.boot.dev/gone", StatusCode: 404},
+		{URL: "https://blog.boot.dev/doc.pdf", StatusCode: 200, ContentType: "application/pdf"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     func(*SEORuleOptions)
+		expected map[string][]string // URL -> rule IDs
+	}{
+		{
+			name: "default rules",
+			opts: func(*SEORuleOptions) {},
+			expected: map[string][]string{
+				"https://blog.boot.dev/bad":  {"title-too-short", "description-missing", "h1-multiple", "h1-empty", "heading-skip", "img-alt-missing", "content-thin", "lang-missing", "canonical-missing"},
+				"https://blog.boot.dev/dup":  {"title-duplicate", "description-duplicate"},
+				"https://blog.boot.dev/good": {"title-duplicate", "description-duplicate"},
+			},
+		},
+		{
+			name: "disabled rules and custom thresholds",
+			opts: func(opts *SEORuleOptions) {
+				opts.Disabled = []string{"title-duplicate", "canonical-missing", "heading-skip", "h1-empty"}
+				opts.MaxTitleLength = 20
+				opts.MinTitleLength = 0
+				opts.MinWordCount = 10
+			},
+			expected: map[string][]string{
+				"https://blog.boot.dev/bad":  {"description-missing", "h1-multiple", "img-alt-missing", "lang-missing"},
+				"https://blog.boot.dev/dup":  {"title-too-long", "description-duplicate"},
+				"https://blog.boot.dev/good": {"title-too-long", "description-duplicate"},
+			},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultSEORuleOptions()
+			tc.opts(&opts)
+			audit := AuditSEO(pages, opts)
+
+			actual := map[string][]string{}
+			for _, page := range audit.Pages {
+				for _, issue := range page.Issues {
+					actual[page.URL] = append(actual[page.URL], issue.Rule)
+				}
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+			if audit.PagesChecked != 3 {
+				t.Errorf("Test %v - %s FAIL: expected 3 pages checked, actual: %d", i, tc.name, audit.PagesChecked)
+			}
+		})
+	}
+}
+
+func TestSEORuleMessages(t *testing.T) {
+	bad := Page{URL: "https://blog.boot.dev/bad", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{
+		Title:            "Short",
+		Headings:         []Heading{{Level: 1, Text: "One"}, {Level: 1, Text: ""}, {Level: 4, Text: "Deep"}},
+		ImageCount:       2,
+		ImagesWithoutAlt: []string{"/a.png"},
+		WordCount:        12,
+	}}
+	long := Page{URL: "https://blog.boot.dev/long", StatusCode: 200, ContentType: "text/html", HeaderCharset: "utf-8", PageMetadata: PageMetadata{
+		Title:           strings.Repeat("Gophers ", 10),
+		MetaDescription: "Shared",
+		Canonical:       "/long",
+		Language:        "en",
+		Headings:        []Heading{{Level: 1, Text: "Gophers"}},
+		WordCount:       500,
+	}}
+	empty := long
+	empty.URL = "https://blog.boot.dev/empty"
+	empty.Title = ""
+	empty.Headings = nil
+
+	expected := map[string][]string{
+		"https://blog.boot.dev/bad": {
+			"title is 5 characters, minimum is 30",
+			"missing meta description",
+			"page has 2 <h1> headings",
+			"<h1> is empty",
+			`<h4> "Deep" follows an <h1>`,
+			"1 of 2 images are missing alt text: /a.png",
+			"page has 12 words, minimum is 300",
+			"missing <html lang>",
+			"missing canonical <link> or Link header",
+			"missing charset in <meta> or Content-Type",
+		},
+		"https://blog.boot.dev/long": {
+			"title is 79 characters, maximum is 60",
+			"meta description is 6 characters, minimum is 70",
+			"meta description is shared by 2 pages",
+		},
+		"https://blog.boot.dev/empty": {
+			"missing <title>",
+			"meta description is 6 characters, minimum is 70",
+			"meta description is shared by 2 pages",
+			"missing <h1>",
+		},
+	}
+
+	audit := AuditSEO([]Page{bad, long, empty}, DefaultSEORuleOptions())
+	actual := map[string][]string{}
+	for _, page := range audit.Pages {
+		for _, issue := range page.Issues {
+			actual[page.URL] = append(actual[page.URL], issue.Message)
+		}
+	}
+	for url, messages := range expected {
+		if !reflect.DeepEqual(actual[url], messages) {
+			t.Errorf("%s FAIL: expected: %q, actual: %q", url, messages, actual[url])
+		}
+	}
+}
+
+func TestValidateSEORuleIDs(t *testing.T) {
+	if err := ValidateSEORuleIDs([]string{"h1-missing", "content-thin"}); err != nil {
+		t.Errorf("expected known rules to pass, got: %v", err)
+	}
+	if err := ValidateSEORuleIDs([]string{"h1-missing", "h7-missing"}); err == nil || !strings.Contains(err.Error(), "h7-missing") {
+		t.Errorf("expected an error naming h7-missing, got: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// maxSitemapSize is the uncompressed size limit from the sitemaps.org protocol.
+	maxSitemapSize = 50 * 1024 * 1024
+	// maxSitemapDepth bounds how deeply sitemap index files may nest.
+	maxSitemapDepth = 5
+)
+
+type SitemapURL struct {
+	Loc        string
+	LastMod    string
+	ChangeFreq string
+	Priority   string
+	Sitemap    string
+}
+
+// sitemapDocument matches both <urlset> and <sitemapindex> roots.
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// discoverSitemaps returns the sitemaps listed in robots.txt plus the
+// conventional /sitemap.xml at the site root.
+func (cfg *Config) discoverSitemaps() []string {
+	defaultSitemap := cfg.BaseURL.Scheme + "://" + cfg.BaseURL.Host + "/sitemap.xml"
+
+	sitemaps := []string{}
+	seen := map[string]bool{}
+	for _, sitemapURL := range append(cfg.robotsFor(cfg.BaseURL).Sitemaps(), defaultSitemap) {
+		if seen[sitemapURL] {
+			continue
+		}
+		seen[sitemapURL] = true
+		sitemaps = append(sitemaps, sitemapURL)
+	}
+	return sitemaps
+}
+
+// LoadSitemaps fetches every discovered sitemap, following sitemap index
+// files, records each entry in cfg.SitemapURLs and returns the page URLs
+// so they can be used as extra crawl seeds.
+func (cfg *Config) LoadSitemaps() []string {
+	seen := map[string]bool{}
+	var seeds []string
+	for _, sitemapURL := range cfg.discoverSitemaps() {
+		seeds = append(seeds, cfg.loadSitemap(sitemapURL, 0, seen)...)
+	}
+	return seeds
+}
+
+func (cfg *Config) loadSitemap(sitemapURL string, depth int, seen map[string]bool) []string {
+	if depth > maxSitemapDepth || seen[sitemapURL] {
+		return nil
+	}
+	seen[sitemapURL] = true
+
+	doc, err := cfg.fetchSitemap(sitemapURL)
+	if err != nil {
+		fmt.Printf("Error - sitemap: %v\n", err)
+		return nil
+	}
+
+	var seeds []string
+	for _, child := range doc.Sitemaps {
+		loc := strings.TrimSpace(child.Loc)
+		if loc == "" {
+			continue
+		}
+		seeds = append(seeds, cfg.loadSitemap(loc, depth+1, seen)...)
+	}
+
+	for _, entry := range doc.URLs {
+		loc := strings.TrimSpace(entry.Loc)
+		if loc == "" {
+			continue
+		}
+		normalizedURL, err := cfg.Normalizer.Normalize(loc)
+		if err != nil {
+			continue
+		}
+
+		cfg.Mu.Lock()
+		if _, exists := cfg.SitemapURLs[normalizedURL]; !exists {
+			cfg.SitemapURLs[normalizedURL] = &SitemapURL{
+				Loc:        loc,
+				LastMod:    strings.TrimSpace(entry.LastMod),
+				ChangeFreq: strings.TrimSpace(entry.ChangeFreq),
+				Priority:   strings.TrimSpace(entry.Priority),
+				Sitemap:    sitemapURL,
+			}
+		}
+		cfg.Mu.Unlock()
+
+		seeds = append(seeds, loc)
+	}
+	return seeds
+}
+
+func (cfg *Config) fetchSitemap(sitemapURL string) (*sitemapDocument, error) {
+	req, err := http.NewRequest("GET", sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request for %s: %v", sitemapURL, err)
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	res, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("got Network error fetching %s: %v", sitemapURL, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got HTTP error fetching %s: %s", sitemapURL, res.Status)
+	}
+
+	doc, err := parseSitemap(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse sitemap %s: %v", sitemapURL, err)
+	}
+	return doc, nil
+}
+
+// parseSitemap decodes a sitemap or sitemap index, transparently
+// decompressing gzip bodies regardless of how they were labelled.
+func parseSitemap(r io.Reader) (*sitemapDocument, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+
+	var body io.Reader = br
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't decompress gzip: %v", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read body: %v", err)
+	}
+	if len(data) > maxSitemapSize {
+		return nil, fmt.Errorf("sitemap exceeds %d bytes", maxSitemapSize)
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("unexpected root element <%s>", doc.XMLName.Local)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,241 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SitemapIssue struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Target     string `json:"target,omitempty"`
+}
+
+// SitemapCoverage compares the URLs listed in the site's sitemaps with the
+// pages reached by following internal links.
+type SitemapCoverage struct {
+	SitemapURLs            int            `json:"sitemap_urls"`
+	Orphans                []string       `json:"orphans"`
+	MissingFromSitemap     []string       `json:"missing_from_sitemap"`
+	BadStatus              []SitemapIssue `json:"bad_status"`
+	Noindexed              []string       `json:"noindexed"`
+	CanonicalisedElsewhere []SitemapIssue `json:"canonicalised_elsewhere"`
+}
+
+// AuditSitemapCoverage must be called after the crawl has finished. Sitemap
+// entries that weren't crawled get a lightweight status check instead.
+func (cfg *Config) AuditSitemapCoverage() *SitemapCoverage {
+	baseURL, _ := cfg.Normalizer.Normalize(cfg.BaseURL.String())
+
+	coverage := &SitemapCoverage{
+		Orphans:                []string{},
+		MissingFromSitemap:     []string{},
+		BadStatus:              []SitemapIssue{},
+		Noindexed:              []string{},
+		CanonicalisedElsewhere: []SitemapIssue{},
+	}
+	var unchecked []string
+
+	cfg.Mu.Lock()
+	coverage.SitemapURLs = len(cfg.SitemapURLs)
+	for normalizedURL, entry := range cfg.SitemapURLs {
+		if _, linked := cfg.Inlinks[normalizedURL]; !linked && normalizedURL != baseURL {
+			coverage.Orphans = append(coverage.Orphans, entry.Loc)
+		}
+
+		data, crawled := cfg.Pages[normalizedURL]
+		if !crawled || data.StatusCode == 0 {
+			unchecked = append(unchecked, entry.Loc)
+			continue
+		}
+		if hops := redirectHopsFrom(entry.Loc, data); len(hops) > 0 {
+			coverage.BadStatus = append(coverage.BadStatus, SitemapIssue{
+				URL:        entry.Loc,
+				StatusCode: hops[0].StatusCode,
+				Target:     hops[len(hops)-1].Location,
+			})
+		} else if data.StatusCode != http.StatusOK {
+			coverage.BadStatus = append(coverage.BadStatus, SitemapIssue{
+				URL:        entry.Loc,
+				StatusCode: data.StatusCode,
+			})
+		}
+		if isNoindex(data) {
+			coverage.Noindexed = append(coverage.Noindexed, entry.Loc)
+		}
+		if canonical, elsewhere := canonicalElsewhere(cfg.Normalizer, normalizedURL, data); elsewhere {
+			coverage.CanonicalisedElsewhere = append(coverage.CanonicalisedElsewhere, SitemapIssue{
+				URL:    entry.Loc,
+				Target: canonical,
+			})
+		}
+	}
+
+	for normalizedURL, data := range cfg.Pages {
+		if _, inSitemap := cfg.SitemapURLs[normalizedURL]; inSitemap {
+			continue
+		}
+		if _, linked := cfg.Inlinks[normalizedURL]; !linked {
+			continue
+		}
+		// pages that shouldn't be indexed are correctly left out of the sitemap
+		if data.StatusCode != http.StatusOK || data.FinalURL != "" || isNoindex(data) {
+			continue
+		}
+		if _, elsewhere := canonicalElsewhere(cfg.Normalizer, normalizedURL, data); elsewhere {
+			continue
+		}
+		coverage.MissingFromSitemap = append(coverage.MissingFromSitemap, data.URL)
+	}
+	cfg.Mu.Unlock()
+
+	for _, result := range cfg.checkURLStatuses(unchecked) {
+		coverage.BadStatus = append(coverage.BadStatus, SitemapIssue{URL: result.URL, StatusCode: result.StatusCode, Target: result.Location})
+	}
+
+	sort.Strings(coverage.Orphans)
+	sort.Strings(coverage.MissingFromSitemap)
+	sort.Strings(coverage.Noindexed)
+	sortSitemapIssues(coverage.BadStatus)
+	sortSitemapIssues(coverage.CanonicalisedElsewhere)
+
+	return coverage
+}
+
+// urlStatus is the response to a single request made outside the crawl.
+type urlStatus struct {
+	URL        string
+	StatusCode int
+	Location   string
+}
+
+// checkURLStatuses requests URLs the crawl didn't reach, sharing the crawl's
+// concurrency and rate limits, and returns the ones that don't answer 200.
+func (cfg *Config) checkURLStatuses(rawURLs []string) []urlStatus {
+	var (
+		results []urlStatus
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+
+	for _, rawURL := range rawURLs {
+		wg.Add(1)
+		go func(rawURL string) {
+			cfg.ConcurrencyControl <- struct{}{}
+			defer func() {
+				<-cfg.ConcurrencyControl
+				wg.Done()
+			}()
+
+			time.Sleep(cfg.RateLimit)
+
+			statusCode, location, err := cfg.checkURLStatus("GET", rawURL)
+			if err != nil {
+				fmt.Printf("Error - checkURLStatus: %v\n", err)
+				return
+			}
+			if statusCode == http.StatusOK {
+				return
+			}
+
+			mu.Lock()
+			results = append(results, urlStatus{URL: rawURL, StatusCode: statusCode, Location: location})
+			mu.Unlock()
+		}(rawURL)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// checkURLStatus requests rawURL without following redirects and returns
+// the status code and, for redirects, the resolved Location.
+func (cfg *Config) checkURLStatus(method, rawURL string) (statusCode int, location string, err error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("couldn't create request for %s: %v", rawURL, err)
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	res, err := cfg.doWithoutRedirects(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("got Network error for %s: %v", rawURL, err)
+	}
+	defer res.Body.Close()
+
+	if loc, err := res.Location(); err == nil {
+		location = loc.String()
+	}
+	return res.StatusCode, location, nil
+}
+
+func isNoindex(data *PageData) bool {
+	for _, directives := range []string{data.Robots, data.XRobotsTag} {
+		for _, directive := range strings.Split(strings.ToLower(directives), ",") {
+			// X-Robots-Tag may scope directives to a crawler, e.g. "googlebot: noindex"
+			if idx := strings.LastIndex(directive, ":"); idx != -1 {
+				directive = directive[idx+1:]
+			}
+			directive = strings.TrimSpace(directive)
+			if directive == "noindex" || directive == "none" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canonicalElsewhere resolves the page's canonical link against the page URL
+// and reports whether it points to a different page.
+func canonicalElsewhere(normalizer URLNormalizer, normalizedURL string, data *PageData) (string, bool) {
+	resolved, normalizedCanonical, ok := resolveCanonical(normalizer, data.URL, effectiveCanonical(data))
+	if !ok || normalizedCanonical == normalizedURL {
+		return "", false
+	}
+	return resolved, true
+}
+
+func sortSitemapIssues(issues []SitemapIssue) {
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].URL < issues[j].URL
+	})
+}
+
+func (coverage *SitemapCoverage) writeText(w io.Writer) {
+	writeSectionHeader(w, "SITEMAP COVERAGE")
+	fmt.Fprintf(w, "%d URLs listed in sitemaps\n", coverage.SitemapURLs)
+
+	fmt.Fprintf(w, "\nIn sitemap but not linked internally (orphans): %d\n", len(coverage.Orphans))
+	for _, u := range coverage.Orphans {
+		fmt.Fprintf(w, "  - %s\n", u)
+	}
+
+	fmt.Fprintf(w, "\nLinked internally but missing from sitemap: %d\n", len(coverage.MissingFromSitemap))
+	for _, u := range coverage.MissingFromSitemap {
+		fmt.Fprintf(w, "  - %s\n", u)
+	}
+
+	fmt.Fprintf(w, "\nSitemap entries with non-200 status or redirects: %d\n", len(coverage.BadStatus))
+	for _, issue := range coverage.BadStatus {
+		if issue.Target != "" {
+			fmt.Fprintf(w, "  - %s: %d, redirects to %s\n", issue.URL, issue.StatusCode, issue.Target)
+		} else {
+			fmt.Fprintf(w, "  - %s: %d\n", issue.URL, issue.StatusCode)
+		}
+	}
+
+	fmt.Fprintf(w, "\nNoindexed sitemap entries: %d\n", len(coverage.Noindexed))
+	for _, u := range coverage.Noindexed {
+		fmt.Fprintf(w, "  - %s\n", u)
+	}
+
+	fmt.Fprintf(w, "\nSitemap entries canonicalised elsewhere: %d\n", len(coverage.CanonicalisedElsewhere))
+	for _, issue := range coverage.CanonicalisedElsewhere {
+		fmt.Fprintf(w, "  - %s -> %s\n", issue.URL, issue.Target)
+	}
+}
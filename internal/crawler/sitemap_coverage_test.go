@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestIsNoindex(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     PageData
+		expected bool
+	}{
+		{name: "no directives", data: PageData{}, expected: false},
+		{name: "meta noindex", data: PageData{PageMetadata: PageMetadata{Robots: "noindex, follow"}}, expected: true},
+		{name: "meta none", data: PageData{PageMetadata: PageMetadata{Robots: "NONE"}}, expected: true},
+		{name: "X-Robots-Tag noindex", data: PageData{XRobotsTag: "noarchive, noindex"}, expected: true},
+		{name: "X-Robots-Tag scoped to a crawler", data: PageData{XRobotsTag: "googlebot: noindex"}, expected: true},
+		{name: "nofollow only", data: PageData{PageMetadata: PageMetadata{Robots: "index, nofollow"}}, expected: false},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isNoindex(&tc.data); actual != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCanonicalElsewhere(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           PageData
+		expectedTarget string
+		expected       bool
+	}{
+		{name: "no canonical", data: PageData{URL: "https://blog.boot.dev/a"}},
+		{name: "self-referencing after normalization", data: PageData{URL: "https://blog.boot.dev/a", PageMetadata: PageMetadata{Canonical: "HTTP://blog.boot.dev/a/"}}},
+		{name: "relative canonical elsewhere", data: PageData{URL: "https://blog.boot.dev/posts/a", PageMetadata: PageMetadata{Canonical: "../b"}}, expectedTarget: "https://blog.boot.dev/b", expected: true},
+		{name: "header canonical elsewhere", data: PageData{URL: "https://blog.boot.dev/a", HeaderCanonical: "/b"}, expectedTarget: "https://blog.boot.dev/b", expected: true},
+		{name: "unparseable canonical", data: PageData{URL: "https://blog.boot.dev/a", PageMetadata: PageMetadata{Canonical: ":\\bad"}}},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			normalizedURL, _ := DefaultURLNormalizer().Normalize(tc.data.URL)
+			target, elsewhere := canonicalElsewhere(DefaultURLNormalizer(), normalizedURL, &tc.data)
+			if target != tc.expectedTarget || elsewhere != tc.expected {
+				t.Errorf("Test %v - %s FAIL: expected: %q %v, actual: %q %v", i, tc.name, tc.expectedTarget, tc.expected, target, elsewhere)
+			}
+		})
+	}
+}
+
+func TestAuditSitemapCoverage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/uncrawled-gone", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/uncrawled-moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/uncrawled-ok", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	baseURL, _ := url.Parse(server.URL)
+
+	cfg := &Config{
+		BaseURL:            baseURL,
+		Normalizer:         DefaultURLNormalizer(),
+		HTTPClient:         NewHTTPClient(DefaultClientOptions()),
+		Mu:                 &sync.Mutex{},
+		ConcurrencyControl: make(chan struct{}, 2),
+		Pages:              map[string]*PageData{},
+		SitemapURLs:        map[string]*SitemapURL{},
+		Inlinks:            map[string]*Inlink{},
+	}
+	add := func(path string, inSitemap, linked bool, data *PageData) {
+		rawURL := server.URL + path
+		key, err := cfg.Normalizer.Normalize(rawURL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if inSitemap {
+			cfg.SitemapURLs[key] = &SitemapURL{Loc: rawURL}
+		}
+		if linked {
+			cfg.Inlinks[key] = &Inlink{Count: 1, Referrers: []string{server.URL + "/"}}
+		}
+		if data != nil {
+			data.URL = rawURL
+			cfg.Pages[key] = data
+		}
+	}
+
+	add("/", true, false, &PageData{StatusCode: 200})
+	add("/orphan", true, false, &PageData{StatusCode: 200})
+	add("/missing", false, true, &PageData{StatusCode: 200})
+	add("/missing-noindex", false, true, &PageData{StatusCode: 200, XRobotsTag: "noindex"})
+	add("/missing-copy", false, true, &PageData{StatusCode: 200, PageMetadata: PageMetadata{Canonical: "/missing"}})
+	add("/gone", true, true, &PageData{StatusCode: 404})
+	add("/moved", true, true, &PageData{StatusCode: 301, FinalURL: server.URL + "/new", Redirects: []RedirectHop{
+		{URL: server.URL + "/moved", StatusCode: 301, Location: server.URL + "/new"},
+	}})
+	// the redirect only added a trailing slash, so the listed URL is fine
+	add("/docs/", true, true, &PageData{StatusCode: 200, Redirects: []RedirectHop{
+		{URL: server.URL + "/docs", StatusCode: 301, Location: server.URL + "/docs/"},
+	}})
+	add("/noindex", true, true, &PageData{StatusCode: 200, PageMetadata: PageMetadata{Robots: "noindex"}})
+	add("/copy", true, true, &PageData{StatusCode: 200, PageMetadata: PageMetadata{Canonical: "/missing"}})
+	add("/uncrawled-gone", true, true, nil)
+	add("/uncrawled-moved", true, true, nil)
+	add("/uncrawled-ok", true, true, nil)
+
+	coverage := cfg.AuditSitemapCoverage()
+
+	tests := []struct {
+		name     string
+		actual   any
+		expected any
+	}{
+		{name: "sitemap URLs", actual: coverage.SitemapURLs, expected: 10},
+		{name: "orphans", actual: coverage.Orphans, expected: []string{server.URL + "/orphan"}},
+		{name: "missing from sitemap", actual: coverage.MissingFromSitemap, expected: []string{server.URL + "/missing"}},
+		{name: "bad status", actual: coverage.BadStatus, expected: []SitemapIssue{
+			{URL: server.URL + "/gone", StatusCode: 404},
+			{URL: server.URL + "/moved", StatusCode: 301, Target: server.URL + "/new"},
+			{URL: server.URL + "/uncrawled-gone", StatusCode: 404},
+			{URL: server.URL + "/uncrawled-moved", StatusCode: 301, Target: server.URL + "/new"},
+		}},
+		{name: "noindexed", actual: coverage.Noindexed, expected: []string{server.URL + "/noindex"}},
+		{name: "canonicalised elsewhere", actual: coverage.CanonicalisedElsewhere, expected: []SitemapIssue{
+			{URL: server.URL + "/copy", Target: server.URL + "/missing"},
+		}},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tc.actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expected, tc.actual)
+			}
+		})
+	}
+}
+
+func TestCheckURLStatuses(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := &Config{
+		HTTPClient:         NewHTTPClient(DefaultClientOptions()),
+		ConcurrencyControl: make(chan struct{}, 2),
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []urlStatus
+	}{
+		{name: "not found", path: "/gone", expected: []urlStatus{{URL: server.URL + "/gone", StatusCode: 404}}},
+		{name: "redirect is not followed", path: "/moved", expected: []urlStatus{{URL: server.URL + "/moved", StatusCode: 302, Location: server.URL + "/new"}}},
+		{name: "ok is not reported", path: "/ok", expected: nil},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := cfg.checkURLStatuses([]string{server.URL + tc.path})
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func gzipString(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatalf("couldn't gzip input: %v", err)
+	}
+	gz.Close()
+	return buf.String()
+}
+
+func TestParseSitemap(t *testing.T) {
+	urlset := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url>
+		<loc>https://blog.boot.dev/</loc>
+		<lastmod>2024-01-02</lastmod>
+		<changefreq>daily</changefreq>
+		<priority>1.0</priority>
+	</url>
+	<url>
+		<loc>https://blog.boot.dev/path</loc>
+	</url>
+</urlset>`
+
+	tests := []struct {
+		name             string
+		input            string
+		expectedURLs     []sitemapEntry
+		expectedSitemaps []sitemapEntry
+		errorContains    string
+	}{
+		{
+			name:  "urlset",
+			input: urlset,
+			expectedURLs: []sitemapEntry{
+				{Loc: "https://blog.boot.dev/", LastMod: "2024-01-02", ChangeFreq: "daily", Priority: "1.0"},
+				{Loc: "https://blog.boot.dev/path"},
+			},
+		},
+		{
+			name: "sitemap index",
+			input: `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://blog.boot.dev/sitemap-posts.xml.gz</loc></sitemap>
+	<sitemap><loc>https://blog.boot.dev/sitemap-pages.xml</loc></sitemap>
+</sitemapindex>`,
+			expectedSitemaps: []sitemapEntry{
+				{Loc: "https://blog.boot.dev/sitemap-posts.xml.gz"},
+				{Loc: "https://blog.boot.dev/sitemap-pages.xml"},
+			},
+		},
+		{
+			name:  "gzip compressed",
+			input: gzipString(t, urlset),
+			expectedURLs: []sitemapEntry{
+				{Loc: "https://blog.boot.dev/", LastMod: "2024-01-02", ChangeFreq: "daily", Priority: "1.0"},
+				{Loc: "https://blog.boot.dev/path"},
+			},
+		},
+		{
+			name:          "unexpected root",
+			input:         `<rss><channel></channel></rss>`,
+			errorContains: "unexpected root element",
+		},
+		{
+			name:          "not XML",
+			input:         `<html><body>not found</body>`,
+			errorContains: "XML syntax error",
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseSitemap(strings.NewReader(tc.input))
+			if err != nil && tc.errorContains == "" {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			} else if err != nil && !strings.Contains(err.Error(), tc.errorContains) {
+				t.Errorf("Test %v - '%s' FAIL: unexpected error: %v", i, tc.name, err)
+				return
+			} else if err == nil && tc.errorContains != "" {
+				t.Errorf("Test %v - '%s' FAIL: expected error containing '%v', got none.", i, tc.name, tc.errorContains)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(actual.URLs, tc.expectedURLs) {
+				t.Errorf("Test %v - '%s' FAIL: expected URLs %v, got %v", i, tc.name, tc.expectedURLs, actual.URLs)
+			}
+			if !reflect.DeepEqual(actual.Sitemaps, tc.expectedSitemaps) {
+				t.Errorf("Test %v - '%s' FAIL: expected sitemaps %v, got %v", i, tc.name, tc.expectedSitemaps, actual.Sitemaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSitemapURLs is the per-file URL limit from the sitemaps.org protocol.
+const maxSitemapURLs = 50000
+
+const (
+	sitemapHeader      = xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n"
+	sitemapFooter      = "</urlset>\n"
+	sitemapIndexHeader = xml.Header + `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n"
+	sitemapIndexFooter = "</sitemapindex>\n"
+)
+
+// indexableSitemapURLs returns the crawled pages that belong in a sitemap:
+// 200 HTML responses that weren't redirected, noindexed or canonicalised
+// elsewhere.
+func indexableSitemapURLs(pages map[string]*PageData, normalizer URLNormalizer) []SitemapURL {
+	entries := []SitemapURL{}
+	for normalizedURL, data := range pages {
+		if !isHTMLPage(data.StatusCode, data.FinalURL, data.ContentType) || isNoindex(data) {
+			continue
+		}
+		if _, elsewhere := canonicalElsewhere(normalizer, normalizedURL, data); elsewhere {
+			continue
+		}
+
+		loc, err := url.Parse(data.URL)
+		if err != nil {
+			continue
+		}
+		loc.Fragment = ""
+
+		entry := SitemapURL{Loc: loc.String()}
+		if lastModified, err := http.ParseTime(data.LastModified); err == nil {
+			entry.LastMod = lastModified.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Loc < entries[j].Loc
+	})
+	return entries
+}
+
+// buildSitemaps renders entries into one or more <urlset> documents, each
+// within the protocol's URL count and uncompressed size limits.
+func buildSitemaps(entries []SitemapURL) [][]byte {
+	var sitemaps [][]byte
+	var current bytes.Buffer
+	count := 0
+
+	flush := func() {
+		current.WriteString(sitemapFooter)
+		sitemaps = append(sitemaps, bytes.Clone(current.Bytes()))
+		current.Reset()
+		count = 0
+	}
+
+	for _, entry := range entries {
+		var element bytes.Buffer
+		element.WriteString("  <url>\n    <loc>")
+		xml.EscapeText(&element, []byte(entry.Loc))
+		element.WriteString("</loc>\n")
+		if entry.LastMod != "" {
+			element.WriteString("    <lastmod>" + entry.LastMod + "</lastmod>\n")
+		}
+		element.WriteString("  </url>\n")
+
+		if count > 0 && (count == maxSitemapURLs || current.Len()+element.Len()+len(sitemapFooter) > maxSitemapSize) {
+			flush()
+		}
+		if count == 0 {
+			current.WriteString(sitemapHeader)
+		}
+		current.Write(element.Bytes())
+		count++
+	}
+
+	if count > 0 || len(sitemaps) == 0 {
+		if count == 0 {
+			current.WriteString(sitemapHeader)
+		}
+		flush()
+	}
+	return sitemaps
+}
+
+func buildSitemapIndex(sitemapURLs []string) []byte {
+	var index bytes.Buffer
+	index.WriteString(sitemapIndexHeader)
+	for _, sitemapURL := range sitemapURLs {
+		index.WriteString("  <sitemap>\n    <loc>")
+		xml.EscapeText(&index, []byte(sitemapURL))
+		index.WriteString("</loc>\n  </sitemap>\n")
+	}
+	index.WriteString(sitemapIndexFooter)
+	return index.Bytes()
+}
+
+// WriteSitemap writes the crawled, indexable pages to outputFile. Above the
+// protocol limits it writes numbered sitemaps next to outputFile and makes
+// outputFile a sitemap index pointing at them from the site root.
+func (cfg *Config) WriteSitemap(outputFile string, compress bool) error {
+	outputFile = strings.TrimSuffix(outputFile, ".gz")
+
+	cfg.Mu.Lock()
+	entries := indexableSitemapURLs(cfg.Pages, cfg.Normalizer)
+	cfg.Mu.Unlock()
+
+	sitemaps := buildSitemaps(entries)
+	if len(sitemaps) == 1 {
+		return writeSitemapFile(outputFile, sitemaps[0], compress)
+	}
+
+	ext := filepath.Ext(outputFile)
+	stem := strings.TrimSuffix(outputFile, ext)
+	siteRoot := cfg.BaseURL.Scheme + "://" + cfg.BaseURL.Host + "/"
+
+	var childURLs []string
+	for i, sitemap := range sitemaps {
+		childFile := fmt.Sprintf("%s-%d%s", stem, i+1, ext)
+		if err := writeSitemapFile(childFile, sitemap, compress); err != nil {
+			return err
+		}
+		childName := filepath.Base(childFile)
+		if compress {
+			childName += ".gz"
+		}
+		childURLs = append(childURLs, siteRoot+childName)
+	}
+
+	return writeSitemapFile(outputFile, buildSitemapIndex(childURLs), compress)
+}
+
+func writeSitemapFile(path string, data []byte, compress bool) error {
+	if compress {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return fmt.Errorf("couldn't compress sitemap: %v", err)
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("couldn't compress sitemap: %v", err)
+		}
+		data = buf.Bytes()
+		path += ".gz"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("couldn't write sitemap: %v", err)
+	}
+	fmt.Printf("Sitemap saved to %s\n", path)
+	return nil
+}
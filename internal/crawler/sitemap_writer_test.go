@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndexableSitemapURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    map[string]*PageData
+		expected []SitemapURL
+	}{
+		{
+			name: "only indexable 200 pages",
+			input: map[string]*PageData{
+				"blog.boot.dev/path":    {URL: "https://blog.boot.dev/path#top", StatusCode: 200, ContentType: "text/html"},
+				"blog.boot.dev/404":     {URL: "https://blog.boot.dev/404", StatusCode: 404},
+				"blog.boot.dev/doc.pdf": {URL: "https://blog.boot.dev/doc.pdf", StatusCode: 200, ContentType: "application/pdf"},
+				"blog.boot.dev/old":     {URL: "https://blog.boot.dev/old", StatusCode: 200, ContentType: "text/html", FinalURL: "https://blog.boot.dev/new"},
+				"blog.boot.dev/tags":    {URL: "https://blog.boot.dev/tags", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{Robots: "noindex, follow"}},
+				"blog.boot.dev/copy":    {URL: "https://blog.boot.dev/copy", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{Canonical: "/path"}},
+				"blog.boot.dev/self":    {URL: "https://blog.boot.dev/self", StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{Canonical: "https://blog.boot.dev/self/"}},
+			},
+			expected: []SitemapURL{
+				{Loc: "https://blog.boot.dev/path"},
+				{Loc: "https://blog.boot.dev/self"},
+			},
+		},
+		{
+			name: "lastmod from Last-Modified",
+			input: map[string]*PageData{
+				"blog.boot.dev": {URL: "https://blog.boot.dev", StatusCode: 200, ContentType: "text/html", LastModified: "Tue, 02 Jan 2024 15:04:05 GMT"},
+			},
+			expected: []SitemapURL{
+				{Loc: "https://blog.boot.dev", LastMod: "2024-01-02T15:04:05Z"},
+			},
+		},
+		{
+			name:     "nil map",
+			input:    nil,
+			expected: []SitemapURL{},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := indexableSitemapURLs(tc.input, DefaultURLNormalizer())
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %v, actual: %v", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBuildSitemaps(t *testing.T) {
+	entries := func(n int) []SitemapURL {
+		result := make([]SitemapURL, n)
+		for i := range result {
+			result[i] = SitemapURL{Loc: fmt.Sprintf("https://blog.boot.dev/%d?a=1&b=2", i)}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name          string
+		input         []SitemapURL
+		expectedFiles int
+	}{
+		{
+			name:          "empty",
+			input:         nil,
+			expectedFiles: 1,
+		},
+		{
+			name:          "single file",
+			input:         entries(10),
+			expectedFiles: 1,
+		},
+		{
+			name:          "split above URL limit",
+			input:         entries(maxSitemapURLs + 1),
+			expectedFiles: 2,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := buildSitemaps(tc.input)
+			if len(actual) != tc.expectedFiles {
+				t.Errorf("Test %v - %s FAIL: expected %d files, actual: %d", i, tc.name, tc.expectedFiles, len(actual))
+				return
+			}
+
+			total := 0
+			for _, sitemap := range actual {
+				doc, err := parseSitemap(strings.NewReader(string(sitemap)))
+				if err != nil {
+					t.Errorf("Test %v - %s FAIL: generated invalid sitemap: %v", i, tc.name, err)
+					return
+				}
+				if len(doc.URLs) > maxSitemapURLs {
+					t.Errorf("Test %v - %s FAIL: sitemap has %d URLs", i, tc.name, len(doc.URLs))
+				}
+				total += len(doc.URLs)
+			}
+			if total != len(tc.input) {
+				t.Errorf("Test %v - %s FAIL: expected %d URLs, actual: %d", i, tc.name, len(tc.input), total)
+			}
+		})
+	}
+}
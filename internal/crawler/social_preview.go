@@ -0,0 +1,295 @@
+package crawler
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPreviewImageHeader bounds how much of a preview image is read to find
+// its dimensions.
+const maxPreviewImageHeader = 1024 * 1024
+
+// previewImageMinimums are the smallest images each preview type accepts.
+var previewImageMinimums = map[string][2]int{
+	"og:image":            {200, 200},
+	"summary":             {144, 144},
+	"summary_large_image": {300, 157},
+}
+
+var twitterCardTypes = []string{"summary", "summary_large_image", "app", "player"}
+
+type SocialPreviewPage struct {
+	URL    string   `json:"url"`
+	Issues []string `json:"issues"`
+}
+
+// SocialPreviewAudit lists pages whose Open Graph or Twitter Card tags would
+// give a broken or missing link preview.
+type SocialPreviewAudit struct {
+	PagesChecked int                 `json:"pages_checked"`
+	Pages        []SocialPreviewPage `json:"pages"`
+}
+
+// previewImage is what fetching a preview image found.
+type previewImage struct {
+	StatusCode  int
+	ContentType string
+	Width       int
+	Height      int
+	Error       string
+}
+
+// AuditSocialPreviews checks the Open Graph and Twitter Card tags of every
+// crawled page, fetching each preview image once to check that it loads and
+// is big enough.
+func (cfg *Config) AuditSocialPreviews() *SocialPreviewAudit {
+	cfg.Mu.Lock()
+	pages := map[string]PageMetadata{}
+	for _, data := range cfg.Pages {
+		if isHTMLPage(data.StatusCode, data.FinalURL, data.ContentType) {
+			pages[data.URL] = data.PageMetadata
+		}
+	}
+	cfg.Mu.Unlock()
+
+	imageURLs := map[string]bool{}
+	for pageURL, meta := range pages {
+		for _, ref := range previewImageRefs(pageURL, meta) {
+			if ref.resolved != "" {
+				imageURLs[ref.resolved] = true
+			}
+		}
+	}
+	images := cfg.fetchPreviewImages(imageURLs)
+
+	audit := &SocialPreviewAudit{PagesChecked: len(pages), Pages: []SocialPreviewPage{}}
+	for pageURL, meta := range pages {
+		if issues := socialPreviewIssues(pageURL, meta, images); len(issues) > 0 {
+			audit.Pages = append(audit.Pages, SocialPreviewPage{URL: pageURL, Issues: issues})
+		}
+	}
+	sort.Slice(audit.Pages, func(i, j int) bool {
+		return audit.Pages[i].URL < audit.Pages[j].URL
+	})
+	return audit
+}
+
+// previewImageRef is one preview image declared by a page.
+type previewImageRef struct {
+	property string // og:image or twitter:image
+	media    OGMedia
+	resolved string // absolute URL, empty when it couldn't be resolved
+}
+
+func previewImageRefs(pageURL string, meta PageMetadata) []previewImageRef {
+	var refs []previewImageRef
+	if meta.OpenGraph != nil {
+		for _, media := range meta.OpenGraph.Images {
+			refs = append(refs, previewImageRef{property: "og:image", media: media})
+		}
+	}
+	if meta.Twitter != nil && meta.Twitter.Image != "" {
+		refs = append(refs, previewImageRef{property: "twitter:image", media: OGMedia{URL: meta.Twitter.Image}})
+	}
+
+	base, err := url.Parse(pageURL)
+	for i := range refs {
+		if refs[i].media.URL == "" || err != nil {
+			continue
+		}
+		if imageURL, err := base.Parse(refs[i].media.URL); err == nil && (imageURL.Scheme == "http" || imageURL.Scheme == "https") {
+			refs[i].resolved = imageURL.String()
+		}
+	}
+	return refs
+}
+
+func socialPreviewIssues(pageURL string, meta PageMetadata, images map[string]previewImage) []string {
+	var issues []string
+	og := meta.OpenGraph
+	if og == nil {
+		og = &OpenGraph{}
+	}
+	card := meta.Twitter
+	if card == nil {
+		card = &TwitterCard{}
+	}
+
+	if og.Title == "" {
+		issue := "missing og:title"
+		if card.Title == "" {
+			issue = "missing og:title and twitter:title"
+		}
+		issues = append(issues, issue)
+	}
+	if og.Description == "" && card.Description == "" {
+		issues = append(issues, "missing og:description and twitter:description")
+	}
+	if og.Type == "" {
+		issues = append(issues, "missing og:type")
+	}
+	if og.URL == "" {
+		issues = append(issues, "missing og:url")
+	} else if !isAbsoluteHTTPURL(og.URL) {
+		issues = append(issues, fmt.Sprintf("og:url %s is not an absolute URL", og.URL))
+	}
+	if len(og.Images) == 0 && card.Image == "" {
+		issues = append(issues, "missing og:image and twitter:image")
+	}
+
+	if card.Card == "" {
+		issues = append(issues, "missing twitter:card")
+	} else if !slices.Contains(twitterCardTypes, card.Card) {
+		issues = append(issues, fmt.Sprintf("twitter:card %q is not one of %s", card.Card, strings.Join(twitterCardTypes, ", ")))
+	}
+
+	for _, ref := range previewImageRefs(pageURL, meta) {
+		issues = append(issues, previewImageIssues(ref, card.Card, images)...)
+	}
+	return issues
+}
+
+func previewImageIssues(ref previewImageRef, cardType string, images map[string]previewImage) []string {
+	media := ref.media
+	if media.URL == "" {
+		return []string{fmt.Sprintf("%s without a URL", ref.property)}
+	}
+	if ref.resolved == "" {
+		return []string{fmt.Sprintf("%s %s is not a valid http(s) URL", ref.property, media.URL)}
+	}
+
+	var issues []string
+	if ref.property == "og:image" && !isAbsoluteHTTPURL(media.URL) {
+		issues = append(issues, fmt.Sprintf("og:image %s is not an absolute URL", media.URL))
+	}
+	declaredWidth, widthErr := parseDimension(media.Width)
+	declaredHeight, heightErr := parseDimension(media.Height)
+	if widthErr != nil {
+		issues = append(issues, fmt.Sprintf("og:image:width %q for %s is not a positive integer", media.Width, media.URL))
+	}
+	if heightErr != nil {
+		issues = append(issues, fmt.Sprintf("og:image:height %q for %s is not a positive integer", media.Height, media.URL))
+	}
+
+	img, fetched := images[ref.resolved]
+	if !fetched {
+		return issues
+	}
+	switch {
+	case img.Error != "":
+		return append(issues, fmt.Sprintf("%s %s couldn't be fetched: %s", ref.property, media.URL, img.Error))
+	case img.StatusCode != http.StatusOK:
+		return append(issues, fmt.Sprintf("%s %s returned %d", ref.property, media.URL, img.StatusCode))
+	case !strings.HasPrefix(img.ContentType, "image/"):
+		return append(issues, fmt.Sprintf("%s %s is not an image (%s)", ref.property, media.URL, img.ContentType))
+	case img.Width == 0:
+		// a format whose dimensions can't be read, such as WebP or SVG
+		return issues
+	}
+
+	minimumFor := "og:image"
+	if ref.property == "twitter:image" {
+		minimumFor = cardType
+	}
+	if minimum, ok := previewImageMinimums[minimumFor]; ok && (img.Width < minimum[0] || img.Height < minimum[1]) {
+		issues = append(issues, fmt.Sprintf("%s %s is %dx%d, smaller than the %dx%d minimum for %s", ref.property, media.URL, img.Width, img.Height, minimum[0], minimum[1], minimumFor))
+	}
+	if (declaredWidth != 0 && declaredWidth != img.Width) || (declaredHeight != 0 && declaredHeight != img.Height) {
+		issues = append(issues, fmt.Sprintf("og:image %s declares %sx%s but is %dx%d", media.URL, media.Width, media.Height, img.Width, img.Height))
+	}
+	return issues
+}
+
+// parseDimension reads an og:image:width or height; an empty value is 0.
+func parseDimension(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid dimension %q", value)
+	}
+	return n, nil
+}
+
+func isAbsoluteHTTPURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// fetchPreviewImages fetches each image with the crawl's concurrency and
+// delay, reading only enough of it to find its dimensions.
+func (cfg *Config) fetchPreviewImages(imageURLs map[string]bool) map[string]previewImage {
+	images := make(map[string]previewImage, len(imageURLs))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for imageURL := range imageURLs {
+		wg.Add(1)
+		go func(imageURL string) {
+			cfg.ConcurrencyControl <- struct{}{}
+			defer func() {
+				<-cfg.ConcurrencyControl
+				wg.Done()
+			}()
+
+			time.Sleep(cfg.RateLimit)
+			img := cfg.fetchPreviewImage(imageURL)
+
+			mu.Lock()
+			images[imageURL] = img
+			mu.Unlock()
+		}(imageURL)
+	}
+	wg.Wait()
+	return images
+}
+
+func (cfg *Config) fetchPreviewImage(imageURL string) previewImage {
+	req, err := http.NewRequest("GET", imageURL, nil)
+	if err != nil {
+		return previewImage{Error: err.Error()}
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	res, err := cfg.HTTPClient.Do(req)
+	if err != nil {
+		return previewImage{Error: err.Error()}
+	}
+	defer res.Body.Close()
+
+	img := previewImage{StatusCode: res.StatusCode, ContentType: res.Header.Get("Content-Type")}
+	if res.StatusCode != http.StatusOK {
+		return img
+	}
+	if config, _, err := image.DecodeConfig(io.LimitReader(res.Body, maxPreviewImageHeader)); err == nil {
+		img.Width, img.Height = config.Width, config.Height
+	}
+	return img
+}
+
+func (audit *SocialPreviewAudit) writeText(w io.Writer) {
+	writeSectionHeader(w, "SOCIAL PREVIEWS")
+	fmt.Fprintf(w, "%d pages checked\n", audit.PagesChecked)
+
+	fmt.Fprintf(w, "\nPages with social preview issues: %d\n", len(audit.Pages))
+	for _, page := range audit.Pages {
+		fmt.Fprintf(w, "  - %s\n", page.URL)
+		for _, issue := range page.Issues {
+			fmt.Fprintf(w, "      %s\n", issue)
+		}
+	}
+}
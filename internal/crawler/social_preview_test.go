@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAuditSocialPreviews(t *testing.T) {
+	mux := http.NewServeMux()
+	servePNG := func(width, height int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			png.Encode(w, image.NewRGBA(image.Rect(0, 0, width, height)))
+		}
+	}
+	mux.HandleFunc("/large.png", servePNG(1200, 630))
+	mux.HandleFunc("/small.png", servePNG(100, 100))
+	mux.HandleFunc("/page.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	complete := func(image OGMedia) PageMetadata {
+		return PageMetadata{
+			OpenGraph: &OpenGraph{Title: "T", Description: "D", Type: "website", URL: server.URL + "/", Images: []OGMedia{image}},
+			Twitter:   &TwitterCard{Card: "summary_large_image"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		meta     PageMetadata
+		expected []string
+	}{
+		{
+			name:     "complete preview",
+			meta:     complete(OGMedia{URL: server.URL + "/large.png", Width: "1200", Height: "630"}),
+			expected: nil,
+		},
+		{
+			name: "no tags",
+			meta: PageMetadata{},
+			expected: []string{
+				"missing og:title and twitter:title",
+				"missing og:description and twitter:description",
+				"missing og:type",
+				"missing og:url",
+				"missing og:image and twitter:image",
+				"missing twitter:card",
+			},
+		},
+		{
+			name: "twitter fallbacks and an invalid card type",
+			meta: PageMetadata{
+				OpenGraph: &OpenGraph{Type: "website", URL: "/relative"},
+				Twitter:   &TwitterCard{Card: "gallery", Title: "T", Description: "D", Image: server.URL + "/large.png"},
+			},
+			expected: []string{
+				"missing og:title",
+				"og:url /relative is not an absolute URL",
+				`twitter:card "gallery" is not one of summary, summary_large_image, app, player`,
+			},
+		},
+		{
+			name: "image too small with wrong declared size",
+			meta: complete(OGMedia{URL: server.URL + "/small.png", Width: "1200", Height: "630"}),
+			expected: []string{
+				"og:image " + server.URL + "/small.png is 100x100, smaller than the 200x200 minimum for og:image",
+				"og:image " + server.URL + "/small.png declares 1200x630 but is 100x100",
+			},
+		},
+		{
+			name: "relative image with invalid width",
+			meta: complete(OGMedia{URL: "/large.png", Width: "wide"}),
+			expected: []string{
+				"og:image /large.png is not an absolute URL",
+				`og:image:width "wide" for /large.png is not a positive integer`,
+			},
+		},
+		{
+			name:     "missing image",
+			meta:     complete(OGMedia{URL: server.URL + "/missing.png"}),
+			expected: []string{"og:image " + server.URL + "/missing.png returned 404"},
+		},
+		{
+			name:     "not an image",
+			meta:     complete(OGMedia{URL: server.URL + "/page.png"}),
+			expected: []string{"og:image " + server.URL + "/page.png is not an image (text/html)"},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				Pages: map[string]*PageData{
+					"page": {URL: server.URL + "/", StatusCode: 200, ContentType: "text/html", PageMetadata: tc.meta},
+				},
+				Mu:                 &sync.Mutex{},
+				ConcurrencyControl: make(chan struct{}, 2),
+				UserAgent:          "Crawler",
+				HTTPClient:         NewHTTPClient(DefaultClientOptions()),
+			}
+			audit := cfg.AuditSocialPreviews()
+			if audit.PagesChecked != 1 {
+				t.Errorf("Test %v - %s FAIL: expected 1 page checked, actual: %d", i, tc.name, audit.PagesChecked)
+			}
+
+			var actual []string
+			if len(audit.Pages) > 0 {
+				actual = audit.Pages[0].Issues
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %q, actual: %q", i, tc.name, tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Structured data formats.
+const (
+	FormatJSONLD    = "json-ld"
+	FormatMicrodata = "microdata"
+	FormatRDFa      = "rdfa"
+)
+
+// StructuredEntity is one top-level item of JSON-LD, Microdata or RDFa.
+// Properties use JSON-LD's shape whatever the source: a value is a string,
+// number, bool, nested object (with its own "@type") or a list of those.
+type StructuredEntity struct {
+	Format     string         `json:"format"`
+	Types      []string       `json:"types"`
+	ID         string         `json:"id,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// schemaPrefixes are stripped from types and property names so the same
+// schema.org term reads the same in every format.
+var schemaPrefixes = []string{"http://schema.org/", "https://schema.org/", "schema:"}
+
+func normalizeSchemaTerm(term string) string {
+	for _, prefix := range schemaPrefixes {
+		if rest, ok := strings.CutPrefix(term, prefix); ok {
+			return rest
+		}
+	}
+	return term
+}
+
+// extractStructuredData reads JSON-LD blocks, Microdata items and RDFa
+// resources. JSON-LD blocks that don't parse are recorded as errors.
+func extractStructuredData(doc *html.Node, meta *PageMetadata) {
+	walkElements(doc, func(n *html.Node) {
+		if n.Data != "script" || !strings.EqualFold(strings.TrimSpace(getAttr(n, "type")), "application/ld+json") {
+			return
+		}
+		entities, err := parseJSONLD(scriptText(n))
+		if err != nil {
+			meta.StructuredDataErrors = append(meta.StructuredDataErrors, err.Error())
+			return
+		}
+		meta.StructuredData = append(meta.StructuredData, entities...)
+	})
+	meta.StructuredData = append(meta.StructuredData, microdataSyntax.extract(doc)...)
+	meta.StructuredData = append(meta.StructuredData, rdfaSyntax.extract(doc)...)
+}
+
+func scriptText(n *html.Node) string {
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			text.WriteString(c.Data)
+		}
+	}
+	// some sites wrap the JSON in a comment or CDATA section for old browsers
+	content := strings.TrimSpace(text.String())
+	for _, wrapper := range [][2]string{{"<!--", "-->"}, {"//<![CDATA[", "//]]>"}, {"<![CDATA[", "]]>"}} {
+		if strings.HasPrefix(content, wrapper[0]) && strings.HasSuffix(content, wrapper[1]) {
+			content = strings.TrimSpace(content[len(wrapper[0]) : len(content)-len(wrapper[1])])
+		}
+	}
+	return content
+}
+
+func parseJSONLD(content string) ([]StructuredEntity, error) {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("couldn't parse JSON-LD: %v", err)
+	}
+
+	var entities []StructuredEntity
+	var collect func(value any)
+	collect = func(value any) {
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]any:
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+				if _, typed := v["@type"]; !typed {
+					return
+				}
+			}
+			entities = append(entities, jsonLDEntity(v))
+		}
+	}
+	collect(data)
+	return entities, nil
+}
+
+func jsonLDEntity(node map[string]any) StructuredEntity {
+	entity := StructuredEntity{Format: FormatJSONLD, Types: []string{}}
+	switch types := node["@type"].(type) {
+	case string:
+		entity.Types = append(entity.Types, normalizeSchemaTerm(types))
+	case []any:
+		for _, t := range types {
+			if s, ok := t.(string); ok {
+				entity.Types = append(entity.Types, normalizeSchemaTerm(s))
+			}
+		}
+	}
+	entity.ID, _ = node["@id"].(string)
+	for key, value := range node {
+		switch key {
+		case "@context", "@type", "@id", "@graph":
+			continue
+		}
+		if entity.Properties == nil {
+			entity.Properties = make(map[string]any)
+		}
+		entity.Properties[key] = value
+	}
+	return entity
+}
+
+// itemSyntax describes how Microdata and RDFa mark up items, which are
+// otherwise read the same way: an item's properties are the descendants
+// naming a property, stopping at nested items.
+type itemSyntax struct {
+	format     string
+	scopeAttr  string
+	typeAttr   string
+	idAttrs    []string
+	propAttr   string
+	valueAttrs map[string]string // element -> attribute holding its value
+}
+
+var microdataSyntax = itemSyntax{
+	format:    FormatMicrodata,
+	scopeAttr: "itemscope",
+	typeAttr:  "itemtype",
+	idAttrs:   []string{"itemid"},
+	propAttr:  "itemprop",
+	valueAttrs: map[string]string{
+		"meta": "content", "audio": "src", "embed": "src", "iframe": "src", "img": "src",
+		"source": "src", "track": "src", "video": "src", "a": "href", "area": "href",
+		"link": "href", "object": "data", "data": "value", "meter": "value", "time": "datetime",
+	},
+}
+
+var rdfaSyntax = itemSyntax{
+	format:    FormatRDFa,
+	scopeAttr: "typeof",
+	typeAttr:  "typeof",
+	idAttrs:   []string{"resource", "about"},
+	propAttr:  "property",
+	// content, resource, href and src are checked first for every element
+	valueAttrs: map[string]string{"object": "data", "time": "datetime"},
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (syntax itemSyntax) isItem(n *html.Node) bool {
+	return n.Type == html.ElementNode && hasAttr(n, syntax.scopeAttr)
+}
+
+// extract returns the top-level items: those that aren't a property of
+// another item.
+func (syntax itemSyntax) extract(doc *html.Node) []StructuredEntity {
+	var entities []StructuredEntity
+	walkElements(doc, func(n *html.Node) {
+		if !syntax.isItem(n) || getAttr(n, syntax.propAttr) != "" {
+			return
+		}
+		entity := StructuredEntity{Format: syntax.format, Types: syntax.types(n), ID: syntax.id(n)}
+		if properties := syntax.properties(n); len(properties) > 0 {
+			entity.Properties = properties
+		}
+		entities = append(entities, entity)
+	})
+	return entities
+}
+
+func (syntax itemSyntax) types(n *html.Node) []string {
+	types := []string{}
+	for _, t := range strings.Fields(getAttr(n, syntax.typeAttr)) {
+		types = append(types, normalizeSchemaTerm(t))
+	}
+	return types
+}
+
+func (syntax itemSyntax) id(n *html.Node) string {
+	for _, key := range syntax.idAttrs {
+		if id := getAttr(n, key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func (syntax itemSyntax) properties(item *html.Node) map[string]any {
+	properties := map[string]any{}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if names := strings.Fields(getAttr(c, syntax.propAttr)); len(names) > 0 {
+				value := syntax.value(c)
+				for _, name := range names {
+					addPropertyValue(properties, normalizeSchemaTerm(name), value)
+				}
+			}
+			if !syntax.isItem(c) {
+				walk(c)
+			}
+		}
+	}
+	walk(item)
+	return properties
+}
+
+// value reads a property: a nested item, the element's value attribute, or
+// its text.
+func (syntax itemSyntax) value(n *html.Node) any {
+	if syntax.isItem(n) {
+		nested := syntax.properties(n)
+		if types := syntax.types(n); len(types) == 1 {
+			nested["@type"] = types[0]
+		} else if len(types) > 1 {
+			list := make([]any, len(types))
+			for i, t := range types {
+				list[i] = t
+			}
+			nested["@type"] = list
+		}
+		if id := syntax.id(n); id != "" {
+			nested["@id"] = id
+		}
+		return nested
+	}
+	if syntax.format == FormatRDFa {
+		// content, then the link attributes, override the text in RDFa
+		for _, key := range []string{"content", "resource", "href", "src"} {
+			if hasAttr(n, key) {
+				return getAttr(n, key)
+			}
+		}
+	}
+	if key, ok := syntax.valueAttrs[n.Data]; ok && hasAttr(n, key) {
+		return getAttr(n, key)
+	}
+	return strings.Join(strings.Fields(nodeText(n)), " ")
+}
+
+func nodeText(n *html.Node) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return text.String()
+}
+
+// addPropertyValue turns a repeated property into a list.
+func addPropertyValue(properties map[string]any, name string, value any) {
+	existing, ok := properties[name]
+	if !ok {
+		properties[name] = value
+		return
+	}
+	if list, ok := existing.([]any); ok {
+		properties[name] = append(list, value)
+		return
+	}
+	properties[name] = []any{existing, value}
+}
+
+// extractJSONLDDescription is the last resort for a description, taken from
+// the first JSON-LD entity that has one.
+func extractJSONLDDescription(doc *html.Node, meta *PageMetadata) {
+	if meta.Description != "" {
+		return
+	}
+	for _, entity := range meta.StructuredData {
+		if description, ok := entity.Properties["description"].(string); ok && entity.Format == FormatJSONLD && description != "" {
+			meta.Description = description
+			return
+		}
+	}
+}
+
+// SchemaTypeUsage lists the pages carrying one schema type.
+type SchemaTypeUsage struct {
+	Type     string   `json:"type"`
+	Entities int      `json:"entities"`
+	Formats  []string `json:"formats"`
+	Pages    []string `json:"pages"`
+}
+
+type StructuredDataError struct {
+	URL    string   `json:"url"`
+	Errors []string `json:"errors"`
+}
+
+// StructuredDataSummary is the site-wide view of which schema types are used
+// and where.
+type StructuredDataSummary struct {
+	PagesChecked            int                   `json:"pages_checked"`
+	PagesWithStructuredData int                   `json:"pages_with_structured_data"`
+	Types                   []SchemaTypeUsage     `json:"types"`
+	Errors                  []StructuredDataError `json:"errors"`
+}
+
+// SummarizeStructuredData groups the structured data of the crawled pages by
+// type, most used first.
+func SummarizeStructuredData(pages []Page) *StructuredDataSummary {
+	summary := &StructuredDataSummary{Types: []SchemaTypeUsage{}, Errors: []StructuredDataError{}}
+	usage := map[string]*SchemaTypeUsage{}
+	for _, page := range pages {
+		if !isHTMLPage(page.StatusCode, page.FinalURL, page.ContentType) {
+			continue
+		}
+		summary.PagesChecked++
+		if len(page.StructuredData) > 0 {
+			summary.PagesWithStructuredData++
+		}
+		if len(page.StructuredDataErrors) > 0 {
+			summary.Errors = append(summary.Errors, StructuredDataError{URL: page.URL, Errors: page.StructuredDataErrors})
+		}
+
+		for _, entity := range page.StructuredData {
+			types := entity.Types
+			if len(types) == 0 {
+				types = []string{"(untyped)"}
+			}
+			for _, t := range types {
+				u, ok := usage[t]
+				if !ok {
+					u = &SchemaTypeUsage{Type: t}
+					usage[t] = u
+				}
+				u.Entities++
+				if !slices.Contains(u.Formats, entity.Format) {
+					u.Formats = append(u.Formats, entity.Format)
+				}
+				if !slices.Contains(u.Pages, page.URL) {
+					u.Pages = append(u.Pages, page.URL)
+				}
+			}
+		}
+	}
+
+	for _, u := range usage {
+		sort.Strings(u.Formats)
+		sort.Strings(u.Pages)
+		summary.Types = append(summary.Types, *u)
+	}
+	sort.Slice(summary.Types, func(i, j int) bool {
+		if len(summary.Types[i].Pages) != len(summary.Types[j].Pages) {
+			return len(summary.Types[i].Pages) > len(summary.Types[j].Pages)
+		}
+		return summary.Types[i].Type < summary.Types[j].Type
+	})
+	sort.Slice(summary.Errors, func(i, j int) bool {
+		return summary.Errors[i].URL < summary.Errors[j].URL
+	})
+	return summary
+}
+
+func (summary *StructuredDataSummary) writeText(w io.Writer) {
+	writeSectionHeader(w, "STRUCTURED DATA")
+	fmt.Fprintf(w, "%d of %d pages carry structured data\n", summary.PagesWithStructuredData, summary.PagesChecked)
+
+	fmt.Fprintf(w, "\nSchema types: %d\n", len(summary.Types))
+	for _, u := range summary.Types {
+		fmt.Fprintf(w, "  - %s: %d entities on %d pages (%s)\n", u.Type, u.Entities, len(u.Pages), strings.Join(u.Formats, ", "))
+		for _, pageURL := range u.Pages {
+			fmt.Fprintf(w, "      %s\n", pageURL)
+		}
+	}
+
+	fmt.Fprintf(w, "\nPages with invalid JSON-LD: %d\n", len(summary.Errors))
+	for _, e := range summary.Errors {
+		fmt.Fprintf(w, "  - %s: %s\n", e.URL, strings.Join(e.Errors, "; "))
+	}
+}
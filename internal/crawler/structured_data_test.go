@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractStructuredData(t *testing.T) {
+	tests := []struct {
+		name           string
+		inputBody      string
+		expected       []StructuredEntity
+		expectedErrors int
+	}{
+		{
+			name: "JSON-LD with escapes and nesting",
+			inputBody: `<script type="application/ld+json">
+				{"@context": "https://schema.org", "@type": "Product", "name": "Say \"hi\"",
+				 "offers": {"@type": "Offer", "price": 9.99}}
+			</script>`,
+			expected: []StructuredEntity{{
+				Format: FormatJSONLD,
+				Types:  []string{"Product"},
+				Properties: map[string]any{
+					"name":   `Say "hi"`,
+					"offers": map[string]any{"@type": "Offer", "price": json.Number("9.99")},
+				},
+			}},
+		},
+		{
+			name: "JSON-LD @graph and top-level array",
+			inputBody: `<script type="application/ld+json"><!--
+				{"@context": "https://schema.org", "@graph": [
+					{"@type": "Organization", "@id": "#org", "name": "Boot.dev"},
+					{"@type": ["WebPage", "https://schema.org/AboutPage"], "name": "About"}
+				]}
+			--></script>
+			<script type="application/ld+json">[{"@type": "BreadcrumbList"}]</script>`,
+			expected: []StructuredEntity{
+				{Format: FormatJSONLD, Types: []string{"Organization"}, ID: "#org", Properties: map[string]any{"name": "Boot.dev"}},
+				{Format: FormatJSONLD, Types: []string{"WebPage", "AboutPage"}, Properties: map[string]any{"name": "About"}},
+				{Format: FormatJSONLD, Types: []string{"BreadcrumbList"}},
+			},
+		},
+		{
+			name:           "invalid JSON-LD",
+			inputBody:      `<script type="application/ld+json">{"@type": "Article",}</script>`,
+			expectedErrors: 1,
+		},
+		{
+			name: "Microdata with nested and repeated properties",
+			inputBody: `<div itemscope itemtype="https://schema.org/Recipe">
+				<h1 itemprop="name">  Pancakes
+				</h1>
+				<img itemprop="image" src="/pancakes.jpg">
+				<meta itemprop="prepTime" content="PT10M">
+				<span itemprop="recipeIngredient">flour</span>
+				<span itemprop="recipeIngredient">milk</span>
+				<div itemprop="author" itemscope itemtype="https://schema.org/Person">
+					<span itemprop="name">Lane</span>
+				</div>
+				<time itemprop="datePublished" datetime="2025-01-02">Jan 2</time>
+			</div>`,
+			expected: []StructuredEntity{{
+				Format: FormatMicrodata,
+				Types:  []string{"Recipe"},
+				Properties: map[string]any{
+					"name":             "Pancakes",
+					"image":            "/pancakes.jpg",
+					"prepTime":         "PT10M",
+					"recipeIngredient": []any{"flour", "milk"},
+					"author":           map[string]any{"@type": "Person", "name": "Lane"},
+					"datePublished":    "2025-01-02",
+				},
+			}},
+		},
+		{
+			name: "RDFa Lite",
+			inputBody: `<div vocab="https://schema.org/" typeof="Event" resource="#launch">
+				<span property="name">Launch</span>
+				<meta property="startDate" content="2025-06-01T10:00">
+				<a property="url" href="https://blog.boot.dev/launch">details</a>
+				<div property="location" typeof="Place"><span property="name">Online</span></div>
+			</div>`,
+			expected: []StructuredEntity{{
+				Format: FormatRDFa,
+				Types:  []string{"Event"},
+				ID:     "#launch",
+				Properties: map[string]any{
+					"name":      "Launch",
+					"startDate": "2025-06-01T10:00",
+					"url":       "https://blog.boot.dev/launch",
+					"location":  map[string]any{"@type": "Place", "name": "Online"},
+				},
+			}},
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><body>" + tc.inputBody + "</body></html>"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var meta PageMetadata
+			extractStructuredData(doc, &meta)
+			if !reflect.DeepEqual(meta.StructuredData, tc.expected) {
+				t.Errorf("Test %v - %s FAIL: expected: %+v, actual: %+v", i, tc.name, tc.expected, meta.StructuredData)
+			}
+			if len(meta.StructuredDataErrors) != tc.expectedErrors {
+				t.Errorf("Test %v - %s FAIL: expected %d errors, actual: %v", i, tc.name, tc.expectedErrors, meta.StructuredDataErrors)
+			}
+		})
+	}
+}
+
+func TestSummarizeStructuredData(t *testing.T) {
+	page := func(url string, errors []string, entities ...StructuredEntity) Page {
+		return Page{URL: url, StatusCode: 200, ContentType: "text/html", PageMetadata: PageMetadata{StructuredData: entities, StructuredDataErrors: errors}}
+	}
+	pages := []Page{
+		page("https://blog.boot.dev/a", nil,
+			StructuredEntity{Format: FormatJSONLD, Types: []string{"Article"}},
+			StructuredEntity{Format: FormatJSONLD, Types: []string{"BreadcrumbList"}}),
+		page("https://blog.boot.dev/b", nil,
+			StructuredEntity{Format: FormatMicrodata, Types: []string{"Article"}},
+			StructuredEntity{Format: FormatJSONLD, Types: []string{"Article"}}),
+		page("https://blog.boot.dev/c", []string{"couldn't parse JSON-LD: bad"}),
+		{URL: "https://blog.boot.dev/gone", StatusCode: 404},
+		{URL: "https://blog.boot.dev/doc.pdf", StatusCode: 200, ContentType: "application/pdf"},
+	}
+
+	expected := &StructuredDataSummary{
+		PagesChecked:            3,
+		PagesWithStructuredData: 2,
+		Types: []SchemaTypeUsage{
+			{Type: "Article", Entities: 3, Formats: []string{FormatJSONLD, FormatMicrodata}, Pages: []string{"https://blog.boot.dev/a", "https://blog.boot.dev/b"}},
+			{Type: "BreadcrumbList", Entities: 1, Formats: []string{FormatJSONLD}, Pages: []string{"https://blog.boot.dev/a"}},
+		},
+		Errors: []StructuredDataError{{URL: "https://blog.boot.dev/c", Errors: []string{"couldn't parse JSON-LD: bad"}}},
+	}
+	if actual := SummarizeStructuredData(pages); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %+v, actual: %+v", expected, actual)
+	}
+}